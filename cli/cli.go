@@ -1,28 +1,67 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/erikjuhani/caplog/config"
 	"github.com/erikjuhani/caplog/core"
-	"github.com/erikjuhani/miniflag"
+	"github.com/erikjuhani/caplog/git"
+	"github.com/erikjuhani/caplog/importer"
+	"github.com/erikjuhani/caplog/site"
 )
 
 var (
-	dir       = miniflag.Flag("getdir", "g", false, "Returns the local repository directory")
-	page      = miniflag.Flag("page", "p", "", "Saves log entry to <sub-directory>/<page>")
-	workspace = miniflag.Flag("workspace", "w", "", "Changes workspace to given <workspace> if it exists")
-	tags      = miniflag.Flag("tag", "t", TagsFlag{}, "Adds `<tag>` to log entry")
-	setConfig = miniflag.Flag("config", "c", ConfigFlag{}, "Changes config setting with `<key=value>`")
+	dir        = newFlag("getdir", "g", false, "Prints the current workspace, same as \"caplog workspace\"")
+	page       = newFlag("page", "p", "", "Selects `<page>`, a sub-directory of the workspace")
+	workspace  = newFlag("workspace", "w", "", "Changes workspace to `<workspace>`, same as \"caplog workspace use\"")
+	tags       = newFlag("tag", "t", TagsFlag{}, "Adds `<tag>` to log entry or searches log entries with the tag")
+	fields     = newFlag("meta", "m", FieldsFlag{}, "Adds metadata `<key=value>` to log entry (ex. mood=good)")
+	setConfig  = newFlag("config", "c", ConfigFlag{}, "Changes config setting with `<key=value>`, same as \"caplog config\"")
+	since      = newFlag("since", "s", "", "Searches log entries written on or after `<date>`")
+	until      = newFlag("until", "u", "", "Searches log entries written on or before `<date>`")
+	regex      = newFlag("regexp", "e", false, "Matches search keywords as regular expressions")
+	allPages   = newFlag("all", "a", false, "Shows log entries from all pages")
+	plain      = newFlag("plain", "n", false, "Shows log entries without colors")
+	format     = newFlag("format", "f", "json", "Exports log entries in `<format>` (json, ndjson, atom, rss)")
+	limit      = newFlag("limit", "l", 0, "Limits to `<n>` most recent log entries")
+	outDir     = newFlag("out", "o", "", "Writes generated files to `<dir>`")
+	importFrom = newFlag("from", "i", "txt", "Imports log entries from `<format>` (jrnl, dayone, txt)")
+	date       = newFlag("date", "d", "", "Writes log entry on `<date>` (ex. 2022-05-16, yesterday or \"yesterday 16:30\")")
+	clock      = newFlag("time", "T", "", "Writes log entry at `<time>` (ex. 16:30)")
+	last       = newFlag("last", "L", false, "Selects the latest log entry")
+	initRepo   = newFlag("init", "I", false, "Initializes a git repository in the added workspace")
+	cloneURL   = newFlag("clone", "C", "", "Clones the added workspace from `<url>`")
+	into       = newFlag("into", "x", "", "Merges tags into `<tag>`")
+	template   = newFlag("template", "b", "", "Pre-fills the editor with `<template>` from the workspace templates")
+	summary    = newFlag("summary", "S", "", "Writes piped input in a code block below `<summary>`")
+	showHelp   = newFlag("help", "h", false, "Shows help")
 )
 
 var (
-	ErrKeyNeedsValueF      = func(k string) error { return fmt.Errorf("key needs a value (ex. %s=<value>)", k) }
-	ErrExpectedOneArgument = func(n int) error { return fmt.Errorf("expected 1 argument, got %d", n) }
-	ErrWriteLog            = func(e error) error { return fmt.Errorf("failed to write log - %w", e) }
+	ErrKeyNeedsValueF = func(k string) error { return fmt.Errorf("key needs a value (ex. %s=<value>)", k) }
+	ErrWriteLog       = func(e error) error { return fmt.Errorf("failed to write log - %w", e) }
+	ErrSearch         = func(e error) error { return fmt.Errorf("failed to search logs - %w", e) }
+	ErrShow           = func(e error) error { return fmt.Errorf("failed to show logs - %w", e) }
+	ErrExport         = func(e error) error { return fmt.Errorf("failed to export logs - %w", e) }
+	ErrSite           = func(e error) error { return fmt.Errorf("failed to generate site - %w", e) }
+	ErrImport         = func(e error) error { return fmt.Errorf("failed to import logs - %w", e) }
+	ErrEditLog        = func(e error) error { return fmt.Errorf("failed to edit log - %w", e) }
+	ErrRemoveLog      = func(e error) error { return fmt.Errorf("failed to remove log - %w", e) }
+	ErrUndo           = func(e error) error { return fmt.Errorf("failed to undo - %w", e) }
+	ErrVerify         = func(e error) error { return fmt.Errorf("failed to verify logs - %w", e) }
+	ErrSync           = func(e error) error { return fmt.Errorf("failed to sync workspace - %w", e) }
+	ErrConfig         = func(e error) error { return fmt.Errorf("failed to configure - %w", e) }
+	ErrMigrate        = func(e error) error { return fmt.Errorf("failed to migrate layout - %w", e) }
+	ErrLastOrDate     = errors.New("expected either --last or <date> <time>")
+	ErrTemplateEntry  = errors.New("template is filled in the editor, log entry cannot be given as an argument")
+	ErrSummaryInput   = errors.New("summary is only written with piped input")
 )
 
 type TagsFlag []string
@@ -31,8 +70,36 @@ func (s *TagsFlag) String() string {
 	return fmt.Sprintf("%s", *s)
 }
 
+// Set adds the comma separated tags skipping empty and repeated tags.
 func (s *TagsFlag) Set(value string) error {
-	*s = append(*s, value)
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); len(t) > 0 && !contains(*s, t) {
+			*s = append(*s, t)
+		}
+	}
+
+	return nil
+}
+
+type FieldsFlag []core.Field
+
+func (f *FieldsFlag) String() string {
+	return fmt.Sprintf("%s", *f)
+}
+
+func (f *FieldsFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return ErrKeyNeedsValueF(k)
+	}
+
+	field, err := core.NewField(k, v)
+	if err != nil {
+		return err
+	}
+
+	*f = append(*f, field)
+
 	return nil
 }
 
@@ -58,73 +125,436 @@ func (c *ConfigFlag) Set(value string) error {
 	return nil
 }
 
+// Run runs the command selected by the command-line arguments. Errors
+// caused by invalid usage are returned as UsageError.
 func Run() error {
-	out := miniflag.CommandLine.Output()
-	miniflag.Parse()
+	return run(os.Stdout, os.Args[1:])
+}
+
+// run parses the arguments with the flags of the selected command and runs
+// the command writing its output to out.
+func run(out io.Writer, args []string) error {
+	positional, flags, err := commandOf(args).parse(args)
+	if err != nil {
+		return err
+	}
+
+	cmd, cmdArgs, err := selectCommand(positional, flags)
+	if err != nil {
+		return err
+	}
+
+	if *showHelp {
+		if len(positional) == 0 {
+			printUsage(out)
+			return nil
+		}
 
-	if *dir {
-		// Return current repository path
-		fmt.Fprintln(out, config.Config.CurrentWorkspace)
+		printCommandUsage(out, cmd)
 		return nil
 	}
 
-	if *workspace != "" {
-		if exists := config.Config.Workspaces.Has(*workspace); !exists {
-			return config.ErrWorkspaceIsNotValid(*workspace, config.Config.Workspaces)
+	if err := cmd.validate(cmdArgs); err != nil {
+		return err
+	}
+
+	return cmd.run(out, cmdArgs)
+}
+
+func configure(out io.Writer, args []string) error {
+	if len(args) == 0 {
+		for _, k := range config.Keys() {
+			v, _ := config.Get(k)
+			fmt.Fprintf(out, "%s=%s\n", k, v)
 		}
+		return nil
+	}
 
-		if err := config.Write(map[string]string{config.CurrentWorkspaceKey: *workspace}); err != nil {
-			return err
+	values := map[string]string{}
+
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok {
+			v, err := config.Get(k)
+			if err != nil {
+				return ErrConfig(err)
+			}
+
+			fmt.Fprintln(out, v)
+			continue
 		}
 
-		fmt.Fprintf(out, "workspace changed to \"%s\"", *workspace)
+		values[k] = v
+	}
 
+	if len(values) == 0 {
 		return nil
 	}
 
-	// Config flags were used needs to do configuration change
-	if len(*setConfig) > 0 {
-		if err := config.Write(*setConfig); err != nil {
-			return err
-		}
+	if err := config.Write(values); err != nil {
+		return ErrConfig(err)
+	}
 
-		for k, v := range *setConfig {
-			fmt.Fprintf(out, "config \"%s\" set as \"%s\"\n", k, v)
+	for k, v := range values {
+		fmt.Fprintf(out, "config \"%s\" set as \"%s\"\n", k, v)
+	}
+
+	return nil
+}
+
+func undo(out io.Writer, args []string) error {
+	if err := core.UndoLog(out); err != nil {
+		return ErrUndo(err)
+	}
+
+	return nil
+}
+
+func generateSite(out io.Writer, args []string) error {
+	if err := site.Build(*outDir); err != nil {
+		return ErrSite(err)
+	}
+
+	fmt.Fprintf(out, "generated site to %s", *outDir)
+
+	return nil
+}
+
+func verifyLogs(out io.Writer, args []string) error {
+	if err := core.VerifyLogs(out); err != nil {
+		return ErrVerify(err)
+	}
+
+	return nil
+}
+
+func syncWorkspace(out io.Writer, args []string) error {
+	if err := git.Sync(config.WorkspacePath()); err != nil {
+		return ErrSync(err)
+	}
+
+	fmt.Fprintf(out, "synced workspace \"%s\"", config.Config.CurrentWorkspace)
+
+	return nil
+}
+
+func migrateLayout(out io.Writer, args []string) error {
+	if err := core.MigrateLayout(out, args[0], config.SetWorkspaceLayout); err != nil {
+		return ErrMigrate(err)
+	}
+
+	return nil
+}
+
+func showLogs(out io.Writer, args []string) error {
+	span := ""
+	if len(args) == 1 {
+		span = args[0]
+	}
+
+	from, to, err := core.ParseSpan(span, time.Now())
+	if err != nil {
+		return ErrShow(err)
+	}
+
+	pages := []string{*page}
+	if *allPages {
+		if pages, err = core.Pages(); err != nil {
+			return ErrShow(err)
 		}
-		return nil
 	}
 
-	return writeLog(out)
+	logs, err := core.ReadLogs(from, to, pages)
+	if err != nil {
+		return ErrShow(err)
+	}
+
+	core.ShowLogs(out, logs, !*plain && isTerminal(out))
+
+	return nil
+}
+
+// isTerminal reports whether the output is an interactive terminal that
+// supports colors.
+func isTerminal(out io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
-func writeLog(out io.Writer) error {
-	args := miniflag.Args()
-	argN := len(args)
+func searchLogs(out io.Writer, keywords []string) error {
+	q, err := query(keywords)
+	if err != nil {
+		return ErrSearch(err)
+	}
+
+	results, err := core.Search(q)
+	if err != nil {
+		return ErrSearch(err)
+	}
 
-	if argN > 1 {
-		return ErrWriteLog(ErrExpectedOneArgument(argN))
+	for _, r := range results {
+		fmt.Fprintf(out, "%s %s\n", r.Date.Format("2006-01-02 15:04"), r.Path)
+		for _, line := range r.Data {
+			fmt.Fprintf(out, "\t%s\n", strings.ReplaceAll(line, "\n", "\n\t"))
+		}
+		fmt.Fprintln(out)
 	}
 
-	if argN == 0 {
-		input, err := core.CaptureEditorInput()
+	return nil
+}
+
+func writeLog(out io.Writer, args []string) error {
+	logDate := time.Now()
+
+	// Log entry is backdated when date or time is given
+	if len(*date) > 0 || len(*clock) > 0 {
+		d, err := core.ParseDateTime(strings.TrimSpace(*date+" "+*clock), logDate)
 		if err != nil {
 			return ErrWriteLog(err)
 		}
+		logDate = d
+	}
+
+	// Log entry is read from piped input with "-" or when input is piped
+	// without an entry or a template
+	piped := (len(args) == 1 && args[0] == "-") || (len(args) == 0 && len(*template) == 0 && isPiped(os.Stdin))
+
+	if len(*summary) > 0 && !piped {
+		return UsageError{Command: "write", Err: ErrSummaryInput}
+	}
+
+	if len(*template) > 0 {
+		if len(args) > 0 {
+			return UsageError{Command: "write", Err: ErrTemplateEntry}
+		}
+
+		return writeTemplateLog(out, logDate)
+	}
+
+	if piped {
+		return writePipedLog(out, logDate)
+	}
 
-		meta := core.Meta{Date: time.Now(), Page: *page}
+	if len(args) == 0 {
+		meta := core.Meta{Date: logDate, Page: *page}
 
-		if err := core.WriteLog(out, core.NewLog(meta, string(input), *tags)); err != nil {
+		input, err := core.CaptureEditorInput(meta, *tags, "")
+		if errors.Is(err, core.ErrEmptyEntry) {
+			return err
+		}
+		if err != nil {
+			return ErrWriteLog(err)
+		}
+
+		if err := core.WriteLog(out, core.NewLog(meta, string(input), *tags, *fields...)); err != nil {
 			return ErrWriteLog(err)
 		}
 
 		return nil
 	}
 
-	meta := core.Meta{Date: time.Now(), Page: *page}
+	meta := core.Meta{Date: logDate, Page: *page}
+
+	if err := core.WriteLog(out, core.NewLog(meta, strings.Join(args, "\n"), *tags, *fields...)); err != nil {
+		return ErrWriteLog(err)
+	}
+
+	return nil
+}
+
+// writePipedLog writes a log entry read from piped input.
+func writePipedLog(out io.Writer, logDate time.Time) error {
+	input, err := core.ReadInput(os.Stdin, *summary)
+	if err != nil {
+		return ErrWriteLog(err)
+	}
+
+	meta := core.Meta{Date: logDate, Page: *page}
+
+	if err := core.WriteLog(out, core.NewLog(meta, input, *tags, *fields...)); err != nil {
+		return ErrWriteLog(err)
+	}
+
+	return nil
+}
+
+// isPiped reports whether the file is piped or redirected input instead of
+// an interactive terminal.
+func isPiped(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice == 0
+}
 
-	if err := core.WriteLog(out, core.NewLog(meta, strings.Join(args, "\n"), *tags)); err != nil {
+// writeTemplateLog writes a log entry filled in the editor from the template.
+// Page of the template is used when no page is given and tags of the template
+// are added to the given tags.
+func writeTemplateLog(out io.Writer, logDate time.Time) error {
+	t, err := core.LoadTemplate(*template)
+	if err != nil {
 		return ErrWriteLog(err)
 	}
 
+	meta := core.Meta{Date: logDate, Page: *page}
+	if len(meta.Page) == 0 {
+		meta.Page = t.Page
+	}
+
+	content, err := t.Render(meta)
+	if err != nil {
+		return ErrWriteLog(err)
+	}
+
+	logTags := append(TagsFlag{}, t.Tags...)
+	for _, tag := range *tags {
+		logTags.Set(tag)
+	}
+
+	input, err := core.CaptureEditorInput(meta, logTags, content)
+	if errors.Is(err, core.ErrEmptyEntry) {
+		return err
+	}
+	if err != nil {
+		return ErrWriteLog(err)
+	}
+
+	if err := core.WriteLog(out, core.NewLog(meta, string(input), logTags, *fields...)); err != nil {
+		return ErrWriteLog(err)
+	}
+
+	return nil
+}
+
+func editLog(out io.Writer, args []string) error {
+	date, n, err := selectedLog(args)
+	if err != nil {
+		return ErrEditLog(err)
+	}
+
+	if err := core.EditLog(out, *page, date, n); err != nil {
+		return ErrEditLog(err)
+	}
+
 	return nil
 }
+
+func removeLog(out io.Writer, args []string) error {
+	args, n := logOrdinal(args)
+
+	date, err := core.ParseDateTime(strings.Join(args, " "), time.Now())
+	if err != nil {
+		return ErrRemoveLog(err)
+	}
+
+	if err := core.RemoveLog(out, *page, date, n); err != nil {
+		return ErrRemoveLog(err)
+	}
+
+	return nil
+}
+
+// selectedLog returns the date and the ordinal of the log entry selected
+// either with <date> <time> [<n>] arguments or zero date with --last flag for
+// the latest log entry.
+func selectedLog(args []string) (time.Time, int, error) {
+	if *last || len(args) == 0 {
+		if len(args) > 0 {
+			return time.Time{}, 0, ErrLastOrDate
+		}
+		return time.Time{}, 0, nil
+	}
+
+	args, n := logOrdinal(args)
+
+	date, err := core.ParseDateTime(strings.Join(args, " "), time.Now())
+
+	return date, n, err
+}
+
+// logOrdinal splits the optional ordinal of the log entry from the end of the
+// <date> <time> [<n>] arguments. The ordinal selects between log entries
+// written on the same minute and is 1 by default.
+func logOrdinal(args []string) ([]string, int) {
+	if len(args) > 1 {
+		if n, err := strconv.Atoi(args[len(args)-1]); err == nil && n > 0 {
+			return args[:len(args)-1], n
+		}
+	}
+
+	return args, 1
+}
+
+func importLogs(out io.Writer, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return ErrImport(err)
+	}
+	defer f.Close()
+
+	logs, err := importer.Read(*importFrom, f, *page)
+	if err != nil {
+		return ErrImport(err)
+	}
+
+	if err := core.ImportLogs(out, logs, filepath.Base(args[0])); err != nil {
+		return ErrImport(err)
+	}
+
+	return nil
+}
+
+func exportLogs(out io.Writer, keywords []string) error {
+	q, err := query(keywords)
+	if err != nil {
+		return ErrExport(err)
+	}
+
+	if err := core.Export(out, q, *format); err != nil {
+		return ErrExport(err)
+	}
+
+	return nil
+}
+
+// query builds a search query from the keywords and the search flags
+func query(keywords []string) (core.Query, error) {
+	q := core.Query{Terms: keywords, Regexp: *regex, Tags: *tags, Limit: *limit}
+
+	if len(*page) > 0 {
+		q.Pages = strings.Split(*page, ",")
+	}
+
+	if len(*since) > 0 {
+		date, err := core.ParseDate(*since)
+		if err != nil {
+			return q, err
+		}
+		q.Since = date
+	}
+
+	if len(*until) > 0 {
+		date, err := core.ParseDate(*until)
+		if err != nil {
+			return q, err
+		}
+		q.Until = date
+	}
+
+	return q, nil
+}
@@ -0,0 +1,548 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Exit codes returned by caplog
+const (
+	ExitFailure = 1
+	ExitUsage   = 2
+)
+
+// Name of the command used when no command is given
+const defaultCommand = "write"
+
+var (
+	ErrUnknownCommandF = func(name string) error { return fmt.Errorf("unknown command \"%s\"", name) }
+	ErrInvalidFlagF    = func(flag string, cmd string) error {
+		return fmt.Errorf("flag --%s is not valid for \"%s\" command", flag, cmd)
+	}
+	ErrInvalidArgumentCountF = func(n int) error { return fmt.Errorf("unexpected number of arguments: %d", n) }
+	ErrLegacyFlagCombination = errors.New("flags --getdir, --workspace and --config cannot be combined with each other or with other commands")
+)
+
+// UsageError is returned when a command is used incorrectly and it exits
+// with ExitUsage.
+type UsageError struct {
+	Command string
+	Err     error
+}
+
+func (e UsageError) Error() string {
+	c, ok := findCommand(e.Command)
+	if !ok {
+		return fmt.Sprintf("%s\nrun \"caplog help\" for usage", e.Err)
+	}
+
+	return fmt.Sprintf("%s\nusage: %s", e.Err, c.usage())
+}
+
+func (e UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for the error returned by Run.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var usageErr UsageError
+	if errors.As(err, &usageErr) {
+		return ExitUsage
+	}
+
+	return ExitFailure
+}
+
+// flagInfo describes a flag for help texts and flag sets of the commands
+type flagInfo struct {
+	name  string
+	short string
+	usage string
+	// define defines the flag with the name in the flag set
+	define func(fs *flag.FlagSet, name string)
+}
+
+// flagInfos are the defined flags in the order they were defined
+var flagInfos []flagInfo
+
+// globalFlags are accepted by every command. Legacy flags --getdir,
+// --workspace and --config select a command of their own.
+var globalFlags = []string{"help", "getdir", "workspace", "config"}
+
+// newFlag records a flag, which is defined in the flag set of each command
+// accepting it, and returns the pointer to its value.
+func newFlag[T any](name string, short string, value T, usage string) *T {
+	p := new(T)
+	*p = value
+
+	f := flagInfo{name: name, short: short, usage: usage}
+
+	switch v := any(p).(type) {
+	case *bool:
+		f.define = func(fs *flag.FlagSet, name string) { fs.BoolVar(v, name, *v, usage) }
+	case *string:
+		f.define = func(fs *flag.FlagSet, name string) { fs.StringVar(v, name, *v, usage) }
+	case *int:
+		f.define = func(fs *flag.FlagSet, name string) { fs.IntVar(v, name, *v, usage) }
+	case flag.Value:
+		f.define = func(fs *flag.FlagSet, name string) { fs.Var(v, name, usage) }
+	default:
+		panic(fmt.Sprintf("unsupported type %T of flag --%s", value, name))
+	}
+
+	flagInfos = append(flagInfos, f)
+
+	return p
+}
+
+func lookupFlag(name string) (flagInfo, bool) {
+	for _, f := range flagInfos {
+		if f.name == name || f.short == name {
+			return f, true
+		}
+	}
+
+	return flagInfo{}, false
+}
+
+// scanArgs returns the positional arguments without parsing the flags. The
+// flags of a command are known only after the command is selected, so the
+// arguments are scanned with all flags to find the command.
+func scanArgs(args []string) []string {
+	var positional []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			return append(positional, args[i+1:]...)
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f, ok := lookupFlag(name); ok && f.takesValue() && !hasValue {
+			i++
+		}
+	}
+
+	return positional
+}
+
+// command is a caplog command with the flags and the number of arguments
+// it accepts. Negative maxArgs accepts any number of arguments. Hidden
+// commands are not listed in the help text.
+type command struct {
+	name    string
+	args    string
+	summary string
+	flags   []string
+	minArgs int
+	maxArgs int
+	hidden  bool
+	run     func(out io.Writer, args []string) error
+}
+
+func (c command) usage() string {
+	usage := "caplog " + c.name
+	if len(c.args) > 0 {
+		usage += " " + c.args
+	}
+	if len(c.flags) > 0 {
+		usage += " [<flags>]"
+	}
+
+	return usage
+}
+
+// flagSet returns the flag set with the flags of the command and the global
+// flags. Errors are returned from parsing instead of printing them.
+func (c command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	for _, name := range append(append([]string{}, c.flags...), globalFlags...) {
+		f, _ := lookupFlag(name)
+		f.define(fs, f.name)
+		f.define(fs, f.short)
+	}
+
+	return fs
+}
+
+// parse parses the flags of the command from the arguments and returns the
+// positional arguments with the long names of the used flags. Flags can be
+// given before and after the positional arguments until "--".
+func (c command) parse(args []string) ([]string, []string, error) {
+	fs := c.flagSet()
+
+	var positional []string
+
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			return nil, nil, UsageError{Command: c.name, Err: c.flagError(fs, args, err)}
+		}
+
+		rest := fs.Args()
+
+		// Everything after the terminator is a positional argument
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	var used []string
+	fs.Visit(func(f *flag.Flag) {
+		if info, ok := lookupFlag(f.Name); ok && !contains(used, info.name) {
+			used = append(used, info.name)
+		}
+	})
+
+	sort.Strings(used)
+
+	return positional, used, nil
+}
+
+// flagError returns ErrInvalidFlagF for a known flag, which the command does
+// not accept, and the parse error otherwise.
+func (c command) flagError(fs *flag.FlagSet, args []string, err error) error {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f, ok := lookupFlag(name); ok && len(arg) > 1 && arg[0] == '-' && fs.Lookup(name) == nil {
+			return ErrInvalidFlagF(f.name, c.name)
+		}
+	}
+
+	return err
+}
+
+// validate checks that the command accepts the number of arguments.
+func (c command) validate(args []string) error {
+	if n := len(args); n < c.minArgs || (c.maxArgs >= 0 && n > c.maxArgs) {
+		return UsageError{Command: c.name, Err: ErrInvalidArgumentCountF(n)}
+	}
+
+	return nil
+}
+
+// commands returns all caplog commands in the order they are listed in the
+// help text.
+func commands() []command {
+	queryFlags := []string{"page", "tag", "since", "until", "regexp", "limit"}
+
+	return []command{
+		{
+			name:    "write",
+			args:    "[<entry>|-]",
+			summary: "Writes a log entry given as an argument, in the editor or from piped input",
+			flags:   []string{"page", "tag", "meta", "date", "time", "template", "summary"},
+			maxArgs: 1,
+			run:     writeLog,
+		},
+		{
+			name:    "show",
+			args:    "[today|yesterday|week|month|<date>]",
+			summary: "Shows log entries written in the given time span",
+			flags:   []string{"page", "all", "plain"},
+			maxArgs: 1,
+			run:     showLogs,
+		},
+		{
+			name:    "search",
+			args:    "[<keyword>...]",
+			summary: "Searches log entries matching all keywords",
+			flags:   queryFlags,
+			maxArgs: -1,
+			run:     searchLogs,
+		},
+		{
+			name:    "export",
+			args:    "[<keyword>...]",
+			summary: "Exports log entries matching all keywords",
+			flags:   append(queryFlags, "format"),
+			maxArgs: -1,
+			run:     exportLogs,
+		},
+		{
+			name:    "edit",
+			args:    "[<date> <time> [<n>]]",
+			summary: "Edits a log entry in the editor, the latest log entry by default",
+			flags:   []string{"page", "last"},
+			maxArgs: 3,
+			run:     editLog,
+		},
+		{
+			name:    "rm",
+			args:    "<date> <time> [<n>]",
+			summary: "Removes a log entry",
+			flags:   []string{"page"},
+			minArgs: 1,
+			maxArgs: 3,
+			run:     removeLog,
+		},
+		{
+			name:    "undo",
+			summary: "Undoes the latest change made by caplog",
+			run:     undo,
+		},
+		{
+			name:    "import",
+			args:    "<file>",
+			summary: "Imports log entries from a file of another journal",
+			flags:   []string{"page", "from"},
+			minArgs: 1,
+			maxArgs: 1,
+			run:     importLogs,
+		},
+		{
+			name:    "site",
+			summary: "Generates a static HTML site of the workspace",
+			flags:   []string{"out"},
+			run:     generateSite,
+		},
+		{
+			name:    "verify",
+			summary: "Verifies the signatures of all log entries",
+			run:     verifyLogs,
+		},
+		{
+			name:    "sync",
+			summary: "Pulls and pushes the workspace to its git remote",
+			run:     syncWorkspace,
+		},
+		{
+			name:    "migrate-layout",
+			args:    "<layout>",
+			summary: "Moves the log files of the workspace to a new layout",
+			minArgs: 1,
+			maxArgs: 1,
+			run:     migrateLayout,
+		},
+		{
+			name:    "config",
+			args:    "[<key>[=<value>]...]",
+			summary: "Shows or sets configuration values",
+			maxArgs: -1,
+			run:     configure,
+		},
+		{
+			name:    "tag",
+			args:    "[list|rename|merge] [<tag>...]",
+			summary: "Lists, renames or merges tags",
+			flags:   []string{"into"},
+			maxArgs: -1,
+			run:     manageTags,
+		},
+		{
+			name:    "workspace",
+			args:    "[use|list|show|add|remove|rename|which] [<workspace>] [<path>|<name>]",
+			summary: "Shows, changes or manages workspaces",
+			flags:   []string{"init", "clone"},
+			maxArgs: 3,
+			run:     manageWorkspaces,
+		},
+		{
+			name:    "completion",
+			args:    "bash|zsh|fish",
+			summary: "Prints the shell completion script for the shell",
+			minArgs: 1,
+			maxArgs: 1,
+			run:     completion,
+		},
+		{
+			name:    "__complete",
+			args:    "workspaces|pages|tags",
+			summary: "Prints the values completed by the shell completion scripts",
+			minArgs: 1,
+			maxArgs: 1,
+			hidden:  true,
+			run:     complete,
+		},
+		{
+			name:    "help",
+			args:    "[<command>]",
+			summary: "Shows help for caplog or for a command",
+			maxArgs: 1,
+			run:     help,
+		},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.name == name {
+			return c, true
+		}
+	}
+
+	return command{}, false
+}
+
+// commandOf returns the command named by the first positional argument or
+// the write command, which parses the flags of the arguments.
+func commandOf(args []string) command {
+	if positional := scanArgs(args); len(positional) > 0 {
+		if c, ok := findCommand(positional[0]); ok {
+			return c
+		}
+	}
+
+	c, _ := findCommand(defaultCommand)
+
+	return c
+}
+
+// selectCommand selects the command and its arguments from the positional
+// arguments. The write command is selected when the first argument is not a
+// command, so `caplog "entry"` writes a log entry. Legacy flags --getdir,
+// --workspace and --config are shorthands for workspace and config commands.
+func selectCommand(args []string, flags []string) (command, []string, error) {
+	var legacy, rest []string
+	for _, f := range flags {
+		switch f {
+		case "getdir", "workspace", "config":
+			legacy = append(legacy, f)
+		case "help":
+		default:
+			rest = append(rest, f)
+		}
+	}
+
+	if len(legacy) > 0 {
+		if len(legacy) > 1 || len(args) > 0 || len(rest) > 0 {
+			return command{}, nil, UsageError{Err: ErrLegacyFlagCombination}
+		}
+
+		switch legacy[0] {
+		case "getdir":
+			c, _ := findCommand("workspace")
+			return c, nil, nil
+		case "workspace":
+			c, _ := findCommand("workspace")
+			return c, []string{"use", *workspace}, nil
+		default:
+			c, _ := findCommand("config")
+
+			var values []string
+			for k, v := range *setConfig {
+				values = append(values, fmt.Sprintf("%s=%s", k, v))
+			}
+			sort.Strings(values)
+
+			return c, values, nil
+		}
+	}
+
+	if len(args) > 0 {
+		if c, ok := findCommand(args[0]); ok {
+			return c, args[1:], nil
+		}
+	}
+
+	c, _ := findCommand(defaultCommand)
+
+	return c, args, nil
+}
+
+func help(out io.Writer, args []string) error {
+	if len(args) == 0 {
+		printUsage(out)
+		return nil
+	}
+
+	c, ok := findCommand(args[0])
+	if !ok {
+		return UsageError{Command: "help", Err: ErrUnknownCommandF(args[0])}
+	}
+
+	printCommandUsage(out, c)
+
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: caplog [<command>] [<args>] [<flags>]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Writes a log entry when no command is given.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range visibleCommands() {
+		fmt.Fprintf(w, "  %-14s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"caplog help <command>\" for the flags of a command.")
+}
+
+func printCommandUsage(w io.Writer, c command) {
+	fmt.Fprintf(w, "usage: %s\n\n%s\n", c.usage(), c.summary)
+
+	if len(c.flags) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	for _, name := range c.flags {
+		f, _ := lookupFlag(name)
+
+		arg, usage := unquoteUsage(f.usage)
+
+		flag := fmt.Sprintf("-%s, --%s", f.short, f.name)
+		if len(arg) > 0 {
+			flag += " " + arg
+		}
+
+		fmt.Fprintf(w, "  %-24s %s\n", flag, usage)
+	}
+}
+
+// unquoteUsage extracts the back-quoted argument name from the usage and
+// returns it with the usage without the back quotes.
+func unquoteUsage(usage string) (string, string) {
+	start := strings.Index(usage, "`")
+	if start < 0 {
+		return "", usage
+	}
+
+	end := strings.Index(usage[start+1:], "`")
+	if end < 0 {
+		return "", usage
+	}
+
+	arg := usage[start+1 : start+1+end]
+
+	return arg, usage[:start] + arg + usage[start+end+2:]
+}
+
+func contains(s []string, v string) bool {
+	for _, vv := range s {
+		if vv == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectCommand(t *testing.T) {
+	prevWorkspace, prevConfig := *workspace, *setConfig
+	defer func() { *workspace, *setConfig = prevWorkspace, prevConfig }()
+
+	*workspace = "notes"
+	*setConfig = ConfigFlag{"editor": "vim", "time_format": "3:04PM"}
+
+	tests := []struct {
+		args         []string
+		flags        []string
+		expected     string
+		expectedArgs []string
+		expectsErr   bool
+	}{
+		{args: nil, expected: "write"},
+		{args: []string{"Wrote the parser"}, expected: "write", expectedArgs: []string{"Wrote the parser"}},
+		{args: []string{"search", "parser", "tests"}, flags: []string{"page"}, expected: "search", expectedArgs: []string{"parser", "tests"}},
+		{args: []string{"help", "search"}, flags: []string{"help"}, expected: "help", expectedArgs: []string{"search"}},
+		{flags: []string{"getdir"}, expected: "workspace"},
+		{flags: []string{"workspace", "help"}, expected: "workspace", expectedArgs: []string{"use", "notes"}},
+		{flags: []string{"config"}, expected: "config", expectedArgs: []string{"editor=vim", "time_format=3:04PM"}},
+		{flags: []string{"getdir", "workspace"}, expectsErr: true},
+		{args: []string{"search"}, flags: []string{"getdir"}, expectsErr: true},
+		{flags: []string{"workspace", "page"}, expectsErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(append(tt.args, tt.flags...), " "), func(t *testing.T) {
+			c, args, err := selectCommand(tt.args, tt.flags)
+			if tt.expectsErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr {
+				return
+			}
+
+			if c.name != tt.expected {
+				t.Fatalf("expected command %s, got %s", tt.expected, c.name)
+			}
+
+			if !reflect.DeepEqual(tt.expectedArgs, args) && len(tt.expectedArgs)+len(args) > 0 {
+				t.Fatalf("expected arguments %q, got %q", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		command    string
+		args       []string
+		expectsErr bool
+	}{
+		{command: "write"},
+		{command: "write", args: []string{"Wrote the parser"}},
+		{command: "write", args: []string{"Wrote", "the parser"}, expectsErr: true},
+		{command: "rm", expectsErr: true},
+		{command: "rm", args: []string{"2022-05-16", "19:20", "2"}},
+		{command: "rm", args: []string{"2022-05-16", "19:20", "2", "3"}, expectsErr: true},
+		{command: "search", args: []string{"parser", "tests", "review"}},
+		{command: "undo", args: []string{"now"}, expectsErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			c, _ := findCommand(tt.command)
+
+			err := c.validate(tt.args)
+			if tt.expectsErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr && ExitCode(err) != ExitUsage {
+				t.Fatalf("expected usage exit code, got %d", ExitCode(err))
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	prevPage, prevRegex := *page, *regex
+	defer func() { *page, *regex = prevPage, prevRegex }()
+
+	tests := []struct {
+		args          []string
+		expected      string
+		expectedArgs  []string
+		expectedFlags []string
+		expectsErr    error
+	}{
+		{
+			args:         []string{"Wrote the parser"},
+			expected:     "write",
+			expectedArgs: []string{"Wrote the parser"},
+		},
+		{
+			args:          []string{"-p", "work", "search", "parser", "-e"},
+			expected:      "search",
+			expectedArgs:  []string{"search", "parser"},
+			expectedFlags: []string{"page", "regexp"},
+		},
+		{
+			args:          []string{"search", "--page=work", "--", "-e"},
+			expected:      "search",
+			expectedArgs:  []string{"search", "-e"},
+			expectedFlags: []string{"page"},
+		},
+		{
+			args:          []string{"-", "-p", "work"},
+			expected:      "write",
+			expectedArgs:  []string{"-"},
+			expectedFlags: []string{"page"},
+		},
+		{
+			args:       []string{"undo", "-p", "work"},
+			expected:   "undo",
+			expectsErr: ErrInvalidFlagF("page", "undo"),
+		},
+		{
+			args:       []string{"search", "--unknown"},
+			expected:   "search",
+			expectsErr: errors.New("flag provided but not defined: -unknown"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			c := commandOf(tt.args)
+			if c.name != tt.expected {
+				t.Fatalf("expected command %s, got %s", tt.expected, c.name)
+			}
+
+			args, flags, err := c.parse(tt.args)
+			if tt.expectsErr != nil {
+				var usageErr UsageError
+				if !errors.As(err, &usageErr) || usageErr.Err.Error() != tt.expectsErr.Error() {
+					t.Fatalf("expected usage error %v, got %v", tt.expectsErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tt.expectedArgs, args) {
+				t.Fatalf("expected arguments %q, got %q", tt.expectedArgs, args)
+			}
+
+			if !reflect.DeepEqual(tt.expectedFlags, flags) {
+				t.Fatalf("expected flags %q, got %q", tt.expectedFlags, flags)
+			}
+		})
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	defer func() { *showHelp = false }()
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"help"}, expected: "usage: caplog [<command>]"},
+		{args: []string{"help", "rm"}, expected: "usage: caplog rm <date> <time> [<n>]"},
+		{args: []string{"search", "-h"}, expected: "usage: caplog search [<keyword>...]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(&out, tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(out.String(), tt.expected) {
+				t.Fatalf("expected help to start with %q, got %q", tt.expected, out.String())
+			}
+		})
+	}
+}
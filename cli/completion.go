@@ -0,0 +1,309 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/core"
+)
+
+var ErrUnknownShellF = func(shell string) error { return fmt.Errorf("unknown shell \"%s\", expected bash, zsh or fish", shell) }
+
+// completedValues maps flags to the kind of values completed for them with
+// the hidden __complete command
+var completedValues = map[string]string{
+	"page":      "pages",
+	"tag":       "tags",
+	"into":      "tags",
+	"workspace": "workspaces",
+	"template":  "templates",
+}
+
+// valueFlags are the flags in completedValues in a stable order
+var valueFlags = []string{"page", "tag", "into", "workspace", "template"}
+
+// completionFlags returns the flags completed for the command. Shorthand
+// flags --workspace and --config are completed only without a command.
+func completionFlags(c command) []flagInfo {
+	names := append([]string{}, c.flags...)
+	if c.name == defaultCommand {
+		names = append(names, "workspace", "config")
+	}
+	names = append(names, "help")
+
+	flags := make([]flagInfo, 0, len(names))
+	for _, name := range names {
+		if f, ok := lookupFlag(name); ok {
+			flags = append(flags, f)
+		}
+	}
+
+	return flags
+}
+
+// takesValue reports whether the flag takes a value, which is named in the
+// usage of the flag.
+func (f flagInfo) takesValue() bool {
+	arg, _ := unquoteUsage(f.usage)
+	return len(arg) > 0
+}
+
+func visibleCommands() []command {
+	var visible []command
+	for _, c := range commands() {
+		if !c.hidden {
+			visible = append(visible, c)
+		}
+	}
+
+	return visible
+}
+
+func commandNames() string {
+	var names []string
+	for _, c := range visibleCommands() {
+		names = append(names, c.name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+func flagNames(c command) string {
+	var names []string
+	for _, f := range completionFlags(c) {
+		names = append(names, "-"+f.short, "--"+f.name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+func completion(out io.Writer, args []string) error {
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(out)
+	case "zsh":
+		writeZshCompletion(out)
+	case "fish":
+		writeFishCompletion(out)
+	default:
+		return UsageError{Command: "completion", Err: ErrUnknownShellF(args[0])}
+	}
+
+	return nil
+}
+
+// complete prints the values completed by the shell completion scripts one
+// per line. Errors are not printed to keep the completion quiet.
+func complete(out io.Writer, args []string) error {
+	var values []string
+
+	switch args[0] {
+	case "workspaces":
+		values = config.Config.Workspaces.Names()
+	case "pages":
+		values, _ = core.Pages()
+	case "tags":
+		values, _ = core.Tags()
+	case "templates":
+		values, _ = core.Templates()
+	}
+
+	for _, v := range values {
+		if len(v) > 0 {
+			fmt.Fprintln(out, v)
+		}
+	}
+
+	return nil
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprint(w, `# bash completion for caplog, source with: source <(caplog completion bash)
+_caplog() {
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local prev="${COMP_WORDS[COMP_CWORD-1]}"
+	local cmd="${COMP_WORDS[1]}"
+	local flags
+
+	case "$prev" in
+`)
+	for _, flag := range valueFlags {
+		f, _ := lookupFlag(flag)
+		fmt.Fprintf(w, "\t-%s|--%s)\n\t\tCOMPREPLY=($(compgen -W \"$(caplog __complete %s 2>/dev/null)\" -- \"$cur\"))\n\t\treturn\n\t\t;;\n", f.short, f.name, completedValues[flag])
+	}
+	fmt.Fprintf(w, `	esac
+
+	if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		return
+	fi
+
+	if [[ $cmd == workspace && $COMP_CWORD -eq 2 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		return
+	fi
+
+	if [[ $cmd == workspace && $COMP_CWORD -eq 3 && $prev =~ ^(use|show|remove|rename)$ ]]; then
+		COMPREPLY=($(compgen -W "$(caplog __complete workspaces 2>/dev/null)" -- "$cur"))
+		return
+	fi
+
+	if [[ $cmd == tag && $COMP_CWORD -eq 2 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		return
+	fi
+
+	if [[ $cmd == tag && $COMP_CWORD -ge 3 && ${COMP_WORDS[2]} =~ ^(rename|merge)$ && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "$(caplog __complete tags 2>/dev/null)" -- "$cur"))
+		return
+	fi
+
+	if [[ ($cmd == help || $cmd == completion) && $COMP_CWORD -eq 2 ]]; then
+		local values="%s"
+		[[ $cmd == completion ]] && values="bash zsh fish"
+		COMPREPLY=($(compgen -W "$values" -- "$cur"))
+		return
+	fi
+
+	case "$cmd" in
+`, commandNames(), workspaceCommandNames(), tagCommandNames(), commandNames())
+	for _, c := range visibleCommands() {
+		if c.name == defaultCommand {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s) flags=\"%s\" ;;\n", c.name, flagNames(c))
+	}
+	def, _ := findCommand(defaultCommand)
+	fmt.Fprintf(w, `	*) flags="%s" ;;
+	esac
+
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+	fi
+}
+
+complete -o default -F _caplog caplog
+`, flagNames(def))
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprint(w, `#compdef caplog
+# zsh completion for caplog, source with: source <(caplog completion zsh)
+_caplog() {
+	local cur="${words[CURRENT]}"
+	local prev="${words[CURRENT-1]}"
+	local cmd="${words[2]}"
+	local -a flags
+
+	case "$prev" in
+`)
+	for _, flag := range valueFlags {
+		f, _ := lookupFlag(flag)
+		fmt.Fprintf(w, "\t-%s|--%s)\n\t\tcompadd -- ${(f)\"$(caplog __complete %s 2>/dev/null)\"}\n\t\treturn\n\t\t;;\n", f.short, f.name, completedValues[flag])
+	}
+	fmt.Fprintf(w, `	esac
+
+	if (( CURRENT == 2 )) && [[ $cur != -* ]]; then
+		compadd -- %s
+		return
+	fi
+
+	if (( CURRENT == 3 )) && [[ $cmd == workspace && $cur != -* ]]; then
+		compadd -- %s
+		return
+	fi
+
+	if (( CURRENT == 4 )) && [[ $cmd == workspace && $prev == (use|show|remove|rename) ]]; then
+		compadd -- ${(f)"$(caplog __complete workspaces 2>/dev/null)"}
+		return
+	fi
+
+	if (( CURRENT == 3 )) && [[ $cmd == tag && $cur != -* ]]; then
+		compadd -- %s
+		return
+	fi
+
+	if (( CURRENT >= 4 )) && [[ $cmd == tag && ${words[3]} == (rename|merge) && $cur != -* ]]; then
+		compadd -- ${(f)"$(caplog __complete tags 2>/dev/null)"}
+		return
+	fi
+
+	if (( CURRENT == 3 )) && [[ $cmd == help ]]; then
+		compadd -- %s
+		return
+	fi
+
+	if (( CURRENT == 3 )) && [[ $cmd == completion ]]; then
+		compadd -- bash zsh fish
+		return
+	fi
+
+	if [[ $cmd == import && $cur != -* ]]; then
+		_files
+		return
+	fi
+
+	case "$cmd" in
+`, commandNames(), workspaceCommandNames(), tagCommandNames(), commandNames())
+	for _, c := range visibleCommands() {
+		if c.name == defaultCommand {
+			continue
+		}
+		fmt.Fprintf(w, "\t%s) flags=(%s) ;;\n", c.name, flagNames(c))
+	}
+	def, _ := findCommand(defaultCommand)
+	fmt.Fprintf(w, `	*) flags=(%s) ;;
+	esac
+
+	if [[ $cur == -* ]]; then
+		compadd -- $flags
+	fi
+}
+
+compdef _caplog caplog
+`, flagNames(def))
+}
+
+func writeFishCompletion(w io.Writer) {
+	names := commandNames()
+
+	fmt.Fprintln(w, "# fish completion for caplog, source with: caplog completion fish | source")
+	fmt.Fprintln(w, "complete -c caplog -f")
+
+	for _, c := range visibleCommands() {
+		fmt.Fprintf(w, "complete -c caplog -n '__fish_use_subcommand' -a %s -d '%s'\n", c.name, c.summary)
+	}
+
+	fmt.Fprintf(w, "complete -c caplog -n '__fish_seen_subcommand_from workspace; and not __fish_seen_subcommand_from %s' -a '%s'\n", workspaceCommandNames(), workspaceCommandNames())
+	fmt.Fprintf(w, "complete -c caplog -n '__fish_seen_subcommand_from workspace; and __fish_seen_subcommand_from use show remove rename' -a '(caplog __complete workspaces 2>/dev/null)'\n")
+	fmt.Fprintf(w, "complete -c caplog -n '__fish_seen_subcommand_from tag; and not __fish_seen_subcommand_from %s' -a '%s'\n", tagCommandNames(), tagCommandNames())
+	fmt.Fprintf(w, "complete -c caplog -n '__fish_seen_subcommand_from tag; and __fish_seen_subcommand_from rename merge' -a '(caplog __complete tags 2>/dev/null)'\n")
+	fmt.Fprintf(w, "complete -c caplog -n '__fish_seen_subcommand_from help' -a '%s'\n", names)
+	fmt.Fprintf(w, "complete -c caplog -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'\n")
+	fmt.Fprintf(w, "complete -c caplog -n '__fish_seen_subcommand_from import' -F\n")
+
+	for _, c := range visibleCommands() {
+		condition := fmt.Sprintf("__fish_seen_subcommand_from %s", c.name)
+		// Flags of the default command are completed also without a command
+		if c.name == defaultCommand {
+			others := strings.TrimSpace(strings.Replace(" "+names+" ", " "+c.name+" ", " ", 1))
+			condition = fmt.Sprintf("not __fish_seen_subcommand_from %s", others)
+		}
+
+		for _, f := range completionFlags(c) {
+			_, usage := unquoteUsage(f.usage)
+
+			line := fmt.Sprintf("complete -c caplog -n '%s' -s %s -l %s", condition, f.short, f.name)
+			if f.takesValue() {
+				line += " -r"
+				if kind, ok := completedValues[f.name]; ok {
+					line += fmt.Sprintf(" -a '(caplog __complete %s 2>/dev/null)'", kind)
+				}
+			}
+
+			fmt.Fprintf(w, "%s -d '%s'\n", line, strings.ReplaceAll(usage, "'", "\\'"))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/erikjuhani/caplog/core"
+)
+
+var (
+	ErrTag                   = func(e error) error { return fmt.Errorf("failed to manage tags - %w", e) }
+	ErrUnknownTagSubcommandF = func(name string) error { return fmt.Errorf("unknown tag command \"%s\"", name) }
+	ErrMergeNeedsInto        = errors.New("tag merge needs the merged tag with --into")
+)
+
+// tagCommands are the sub-commands of the tag command with the number of
+// arguments they accept
+var tagCommands = []struct {
+	name    string
+	minArgs int
+	maxArgs int
+	run     func(out io.Writer, args []string) error
+}{
+	{name: "list", run: listTags},
+	{name: "rename", minArgs: 2, maxArgs: 2, run: renameTag},
+	{name: "merge", minArgs: 1, maxArgs: -1, run: mergeTags},
+}
+
+func tagCommandNames() string {
+	var names []string
+	for _, c := range tagCommands {
+		names = append(names, c.name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+// manageTags lists the tags without arguments and runs the tag sub-command
+// otherwise.
+func manageTags(out io.Writer, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	// Flag --into is defined for the tag command, but only the merge
+	// sub-command accepts it
+	if args[0] != "merge" && len(*into) > 0 {
+		return UsageError{Command: "tag", Err: ErrInvalidFlagF("into", "tag "+args[0])}
+	}
+
+	if args[0] == "merge" && len(*into) == 0 {
+		return UsageError{Command: "tag", Err: ErrMergeNeedsInto}
+	}
+
+	for _, c := range tagCommands {
+		if c.name != args[0] {
+			continue
+		}
+
+		if n := len(args) - 1; n < c.minArgs || (c.maxArgs >= 0 && n > c.maxArgs) {
+			return UsageError{Command: "tag", Err: ErrInvalidArgumentCountF(n)}
+		}
+
+		if err := c.run(out, args[1:]); err != nil {
+			return ErrTag(err)
+		}
+
+		return nil
+	}
+
+	return UsageError{Command: "tag", Err: ErrUnknownTagSubcommandF(args[0])}
+}
+
+// listTags prints the tags with the number of log entries using them and the
+// date they were used last.
+func listTags(out io.Writer, args []string) error {
+	usages, err := core.TagUsages()
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, u := range usages {
+		if len(u.Name) > width {
+			width = len(u.Name)
+		}
+	}
+
+	for _, u := range usages {
+		fmt.Fprintf(out, "%-*s  %5d  %s\n", width, u.Name, u.Count, u.LastUsed.Format("2006-01-02"))
+	}
+
+	return nil
+}
+
+func renameTag(out io.Writer, args []string) error {
+	return core.RenameTag(out, args[0], args[1])
+}
+
+func mergeTags(out io.Writer, args []string) error {
+	return core.MergeTags(out, args, *into)
+}
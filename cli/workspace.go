@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+var (
+	ErrWorkspace            = func(e error) error { return fmt.Errorf("failed to change workspaces - %w", e) }
+	ErrUnknownSubcommandF   = func(name string) error { return fmt.Errorf("unknown workspace command \"%s\"", name) }
+	ErrPathIsNotDirectoryF  = func(path string) error { return fmt.Errorf("%s is not a directory", path) }
+	ErrCloneTargetNotEmptyF = func(path string) error { return fmt.Errorf("cannot clone to %s, directory is not empty", path) }
+	ErrInitAndClone         = errors.New("flags --init and --clone cannot be combined")
+)
+
+// workspaceCommands are the sub-commands of the workspace command with the
+// number of arguments they accept
+var workspaceCommands = []struct {
+	name    string
+	minArgs int
+	maxArgs int
+	run     func(out io.Writer, args []string) error
+}{
+	{name: "use", minArgs: 1, maxArgs: 1, run: useWorkspace},
+	{name: "list", run: listWorkspaces},
+	{name: "show", maxArgs: 1, run: showWorkspace},
+	{name: "add", minArgs: 2, maxArgs: 2, run: addWorkspace},
+	{name: "remove", minArgs: 1, maxArgs: 1, run: removeWorkspace},
+	{name: "rename", minArgs: 2, maxArgs: 2, run: renameWorkspace},
+	{name: "which", run: whichWorkspace},
+}
+
+func workspaceCommandNames() string {
+	var names []string
+	for _, c := range workspaceCommands {
+		names = append(names, c.name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+// manageWorkspaces prints the current workspace without arguments and runs
+// the workspace sub-command otherwise.
+func manageWorkspaces(out io.Writer, args []string) error {
+	// Flags --init and --clone are defined for the workspace command, but
+	// only the add sub-command accepts them
+	used := map[string]bool{"init": *initRepo, "clone": len(*cloneURL) > 0}
+	if len(args) == 0 || args[0] != "add" {
+		name := "workspace"
+		if len(args) > 0 {
+			name += " " + args[0]
+		}
+
+		for _, f := range []string{"init", "clone"} {
+			if used[f] {
+				return UsageError{Command: "workspace", Err: ErrInvalidFlagF(f, name)}
+			}
+		}
+	}
+
+	if used["init"] && used["clone"] {
+		return UsageError{Command: "workspace", Err: ErrInitAndClone}
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(out, config.Config.CurrentWorkspace)
+		return nil
+	}
+
+	for _, c := range workspaceCommands {
+		if c.name != args[0] {
+			continue
+		}
+
+		if n := len(args) - 1; n < c.minArgs || n > c.maxArgs {
+			return UsageError{Command: "workspace", Err: ErrInvalidArgumentCountF(n)}
+		}
+
+		if err := c.run(out, args[1:]); err != nil {
+			return ErrWorkspace(err)
+		}
+
+		return nil
+	}
+
+	return UsageError{Command: "workspace", Err: ErrUnknownSubcommandF(args[0])}
+}
+
+func useWorkspace(out io.Writer, args []string) error {
+	name := args[0]
+
+	if exists := config.Config.Workspaces.Has(name); !exists {
+		return config.ErrWorkspaceIsNotValid(name, config.Config.Workspaces)
+	}
+
+	if err := config.Write(map[string]string{config.CurrentWorkspaceKey: name}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "workspace changed to \"%s\"", name)
+
+	if config.Selected.Source == config.SourceLocal {
+		fmt.Fprintf(out, "\nlocal workspace \"%s\" from %s is still used in this directory", config.Selected.Workspace, config.Selected.Path)
+	}
+
+	return nil
+}
+
+// whichWorkspace prints the current workspace and why it was chosen.
+func whichWorkspace(out io.Writer, args []string) error {
+	fmt.Fprintln(out, config.Selected)
+	return nil
+}
+
+func listWorkspaces(out io.Writer, args []string) error {
+	width := 0
+	for _, w := range config.Config.Workspaces {
+		if len(w.Name) > width {
+			width = len(w.Name)
+		}
+	}
+
+	for _, w := range config.Config.Workspaces {
+		marker := " "
+		if w.Name == config.Config.CurrentWorkspace {
+			marker = "*"
+		}
+
+		fmt.Fprintf(out, "%s %-*s  %s\n", marker, width, w.Name, w.Path)
+	}
+
+	return nil
+}
+
+func showWorkspace(out io.Writer, args []string) error {
+	name := config.Config.CurrentWorkspace
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	w, ok := config.Config.Workspaces.Lookup(name)
+	if !ok {
+		return config.ErrWorkspaceIsNotValid(name, config.Config.Workspaces)
+	}
+
+	path := config.ExpandHome(w.Path)
+
+	repository, remote := "no", "none"
+	if git.IsRepository(path) {
+		repository = "yes"
+
+		if url, err := git.RemoteURL(path); err == nil {
+			remote = url
+		}
+	}
+
+	encrypted := "no"
+	if _, ok := config.Config.Encryption[w.Name]; ok {
+		encrypted = "yes"
+	}
+
+	current := "no"
+	if w.Name == config.Config.CurrentWorkspace {
+		current = "yes"
+	}
+
+	fmt.Fprintf(out, "name:       %s\n", w.Name)
+	fmt.Fprintf(out, "path:       %s\n", w.Path)
+	fmt.Fprintf(out, "current:    %s\n", current)
+	fmt.Fprintf(out, "repository: %s\n", repository)
+	fmt.Fprintf(out, "remote:     %s\n", remote)
+	fmt.Fprintf(out, "encrypted:  %s\n", encrypted)
+
+	return nil
+}
+
+// addWorkspace adds the workspace to the config after creating its directory.
+// The directory is either cloned from a remote or optionally initialized as a
+// git repository.
+func addWorkspace(out io.Writer, args []string) error {
+	name, path := args[0], args[1]
+
+	// Name is validated before the workspace directory is cloned or created,
+	// so an invalid name does not leave an orphan directory behind
+	if err := config.ValidateWorkspaceName(name); err != nil {
+		return err
+	}
+
+	if config.Config.Workspaces.Has(name) {
+		return config.ErrWorkspaceExists(name)
+	}
+
+	// Paths relative to home directory are kept as they are in the config
+	if !strings.HasPrefix(path, "~") {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		path = abs
+	}
+
+	dir := config.ExpandHome(path)
+
+	if err := prepareWorkspaceDir(dir); err != nil {
+		return err
+	}
+
+	if err := config.AddWorkspace(name, path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "added workspace \"%s\" at %s", name, path)
+
+	return nil
+}
+
+func prepareWorkspaceDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil && !info.IsDir() {
+		return ErrPathIsNotDirectoryF(dir)
+	}
+
+	if len(*cloneURL) > 0 {
+		if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+			return ErrCloneTargetNotEmptyF(dir)
+		}
+
+		return git.Clone(*cloneURL, dir)
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	if *initRepo {
+		return git.Init(dir)
+	}
+
+	return nil
+}
+
+// removeWorkspace removes the workspace from the config. Files in the
+// workspace are left as they are.
+func removeWorkspace(out io.Writer, args []string) error {
+	name := args[0]
+
+	w, ok := config.Config.Workspaces.Lookup(name)
+	if !ok {
+		return config.ErrWorkspaceIsNotValid(name, config.Config.Workspaces)
+	}
+
+	if err := config.RemoveWorkspace(name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "removed workspace \"%s\", files in %s were not removed", name, w.Path)
+
+	return nil
+}
+
+func renameWorkspace(out io.Writer, args []string) error {
+	if err := config.RenameWorkspace(args[0], args[1]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "renamed workspace \"%s\" to \"%s\"", args[0], args[1])
+
+	return nil
+}
@@ -1,10 +1,16 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/erikjuhani/caplog/layout"
+	"github.com/erikjuhani/caplog/locale"
 	"github.com/pelletier/go-toml/v2"
 )
 
@@ -13,19 +19,56 @@ const (
 	CurrentWorkspaceKey = "current_workspace"
 	WorkspacesKey       = "workspaces"
 	EditorKey           = "editor"
+	SigningKeyKey       = "signing_key"
+	TimeFormatKey       = "time_format"
+	DateFormatKey       = "date_format"
+	LocaleKey           = "locale"
+	MaxInputSizeKey     = "max_input_size"
 )
 
 // Default path location constants
 const (
 	defaultConfigLocation = "~/.caplog.toml"
 	defaultRepositoryPath = "%s/.caplog/capbook"
+	defaultWorkspace      = "default"
 )
 
+// Default formats of log entry times and front matter dates
+const (
+	DefaultTimeFormat = "15:04"
+	DefaultDateFormat = "Monday, January 2, 2006"
+)
+
+// DefaultMaxInputSize is the size limit of log entries read from piped input
+const DefaultMaxInputSize = 64 << 10
+
+// PassphraseEnv is the environment variable holding the passphrase of
+// encrypted workspaces without a key file
+const PassphraseEnv = "CAPLOG_PASSPHRASE"
+
 var (
 	ErrWorkspaceIsNotValid = func(workspace string, validWorkspaces Workspaces) error {
 		return fmt.Errorf("given \"%s\" workspace is not a valid workspace\nvalid workspaces are: %v", workspace, validWorkspaces.Names())
 	}
-	ErrConfigKeyIsNotValid           = func(k string) error { return fmt.Errorf("\"%s\" is not a valid configuration key", k) }
+	ErrConfigKeyIsNotValid = func(k string) error { return fmt.Errorf("\"%s\" is not a valid configuration key", k) }
+	ErrNoPassphrase        = func(workspace string) error {
+		return fmt.Errorf("encrypted workspace \"%s\" needs a key_file or a passphrase in %s", workspace, PassphraseEnv)
+	}
+	ErrWorkspaceExists      = func(workspace string) error { return fmt.Errorf("workspace \"%s\" already exists", workspace) }
+	ErrInvalidWorkspaceName = func(workspace string) error { return fmt.Errorf("invalid workspace name \"%s\"", workspace) }
+	ErrDefaultWorkspace     = errors.New("default workspace cannot be changed")
+	ErrInvalidTimeFormatF   = func(f string) error {
+		return fmt.Errorf("invalid time_format \"%s\", expected a time layout like 15:04 or 3:04PM", f)
+	}
+	ErrInvalidDateFormatF = func(f string) error {
+		return fmt.Errorf("invalid date_format \"%s\", expected a date layout like Monday, January 2, 2006", f)
+	}
+	ErrInvalidWorkspaceFormatsF = func(workspace string, e error) error {
+		return fmt.Errorf("invalid formats of workspace \"%s\" - %w", workspace, e)
+	}
+	ErrInvalidMaxInputSizeF = func(s string) error {
+		return fmt.Errorf("invalid max_input_size \"%s\", expected a size like 65536, 64K or 1M", s)
+	}
 	ErrNotEnoughtArgsToSetWorkspaces = fmt.Errorf("not enough arguments to set workspaces, set the value with double colon separator \"workspace:path\"")
 )
 
@@ -42,6 +85,9 @@ var (
 	// The actual path to the nearest config file
 	configPath string
 
+	// Layouts parsed from their templates
+	parsedLayouts = map[string]layout.Layout{}
+
 	// Paths that are searched for configuration file
 	validConfigPaths = []string{
 		defaultConfigLocation,
@@ -55,6 +101,31 @@ type Workspace struct {
 	Path string `toml:"path"`
 }
 
+// Encryption enables encryption for a workspace. The key is derived either
+// from the key file or from the passphrase environment variable.
+type Encryption struct {
+	KeyFile string `toml:"key_file,omitempty"`
+}
+
+// Passphrase returns the passphrase of the encrypted workspace read either
+// from the key file or from the passphrase environment variable.
+func (e Encryption) Passphrase(workspace string) ([]byte, error) {
+	if len(e.KeyFile) > 0 {
+		b, err := os.ReadFile(replaceTilde(e.KeyFile, HomeDir))
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.TrimSpace(b), nil
+	}
+
+	if p := os.Getenv(PassphraseEnv); len(p) > 0 {
+		return []byte(p), nil
+	}
+
+	return nil, ErrNoPassphrase(workspace)
+}
+
 type Workspaces []Workspace
 
 func (w *Workspaces) Append(name string, path string) {
@@ -71,6 +142,25 @@ func (w Workspaces) Has(workspace string) bool {
 	return false
 }
 
+func (w Workspaces) index(workspace string) int {
+	for i, v := range w {
+		if v.Name == workspace {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Lookup returns the workspace with the given name.
+func (w Workspaces) Lookup(workspace string) (Workspace, bool) {
+	if i := w.index(workspace); i >= 0 {
+		return w[i], true
+	}
+
+	return Workspace{}, false
+}
+
 func (w Workspaces) Names() []string {
 	var n []string
 	for _, v := range w {
@@ -80,6 +170,176 @@ func (w Workspaces) Names() []string {
 	return n
 }
 
+// WorkspaceEncryption returns the encryption of the current workspace and
+// whether the current workspace is encrypted.
+func WorkspaceEncryption() (Encryption, bool) {
+	e, ok := Config.Encryption[Config.CurrentWorkspace]
+	return e, ok
+}
+
+// WorkspaceLayout returns the layout of the log files in the current
+// workspace.
+func WorkspaceLayout() layout.Layout {
+	t := Config.Layouts[Config.CurrentWorkspace]
+
+	if l, ok := parsedLayouts[t]; ok {
+		return l
+	}
+
+	// Layouts are validated when the configuration is loaded
+	l, err := layout.Parse(t)
+	if err != nil {
+		l, _ = layout.Parse(layout.Default)
+	}
+
+	parsedLayouts[t] = l
+
+	return l
+}
+
+// SetWorkspaceLayout changes the layout of the current workspace. Layout of
+// a local workspace is changed in its local config.
+func SetWorkspaceLayout(template string) error {
+	l, err := layout.Parse(template)
+	if err != nil {
+		return err
+	}
+
+	name := Config.CurrentWorkspace
+
+	if Selected.Source == SourceLocal {
+		ok, err := setLocalLayout(Selected.Path, l.Template)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			Config.Layouts = setLayout(Config.Layouts, name, l.Template)
+			return nil
+		}
+	}
+
+	if err := update(configPath, func(c *config) error {
+		c.Layouts = setLayout(c.Layouts, name, l.Template)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	Config.Layouts = setLayout(Config.Layouts, name, l.Template)
+
+	return nil
+}
+
+// setLayout sets the layout of the workspace, the default layout is not
+// stored.
+func setLayout(layouts map[string]string, workspace string, template string) map[string]string {
+	if template == layout.Default {
+		delete(layouts, workspace)
+		return layouts
+	}
+
+	if layouts == nil {
+		layouts = map[string]string{}
+	}
+	layouts[workspace] = template
+
+	return layouts
+}
+
+// TimeFormat returns the time layout of log entry times in the current
+// workspace.
+func TimeFormat() string {
+	f := workspaceFormats(&Config, Config.CurrentWorkspace).TimeFormat
+	if len(f) == 0 {
+		return DefaultTimeFormat
+	}
+
+	return f
+}
+
+// DateFormat returns the date layout of front matter dates in the current
+// workspace.
+func DateFormat() string {
+	f := workspaceFormats(&Config, Config.CurrentWorkspace).DateFormat
+	if len(f) == 0 {
+		return DefaultDateFormat
+	}
+
+	return f
+}
+
+// Locale returns the locale of weekday and month names in dates in the
+// current workspace.
+func Locale() locale.Locale {
+	// Locale is validated when the configuration is loaded
+	l, _ := locale.Lookup(workspaceFormats(&Config, Config.CurrentWorkspace).Locale)
+	return l
+}
+
+// MaxInputSize returns the size limit in bytes of log entries read from piped
+// input.
+func MaxInputSize() int64 {
+	// Size is validated when the configuration is loaded
+	n, err := parseSize(Config.MaxInputSize)
+	if err != nil {
+		return DefaultMaxInputSize
+	}
+
+	return n
+}
+
+// parseSize parses a size in bytes with an optional K or M suffix, which are
+// multiples of 1024. An empty size is the default size.
+func parseSize(s string) (int64, error) {
+	if len(s) == 0 {
+		return DefaultMaxInputSize, nil
+	}
+
+	num := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"KIB", 1 << 10}, {"KB", 1 << 10}, {"K", 1 << 10}, {"MIB", 1 << 20}, {"MB", 1 << 20}, {"M", 1 << 20}} {
+		if strings.HasSuffix(num, u.suffix) {
+			num, unit = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/unit {
+		return 0, ErrInvalidMaxInputSizeF(s)
+	}
+
+	return n * unit, nil
+}
+
+// validateFormats checks that log entry times and front matter dates
+// formatted with the configured formats, and with the formats of each
+// workspace, can be parsed back.
+func validateFormats(config *config) error {
+	if err := workspaceFormats(config, "").validate(); err != nil {
+		return err
+	}
+
+	for w := range config.Formats {
+		if err := workspaceFormats(config, w).validate(); err != nil {
+			return ErrInvalidWorkspaceFormatsF(w, err)
+		}
+	}
+
+	return nil
+}
+
+// SigningKeyPath returns the path to the signing key or an empty string when
+// signing is not enabled.
+func SigningKeyPath() string {
+	return replaceTilde(Config.SigningKey, HomeDir)
+}
+
 func WorkspacePath() string {
 	return workspacePath(HomeDir, &Config)
 }
@@ -98,17 +358,46 @@ type config struct {
 	CurrentWorkspace string     `toml:"current_workspace,omitempty"`
 	Workspaces       Workspaces `toml:"workspaces,inline,omitempty"`
 	Editor           string     `toml:"editor,omitempty"`
+	// SigningKey is the path to the key used for signing log entries
+	SigningKey string `toml:"signing_key,omitempty"`
+	// Encryption maps encrypted workspaces to their encryption settings
+	Encryption map[string]Encryption `toml:"encryption,omitempty"`
+	// Layouts maps workspaces to the path templates of their log files
+	Layouts map[string]string `toml:"layout,omitempty"`
+	// Tags maps workspaces to the policies of the tags written in them
+	Tags map[string]TagPolicy `toml:"tags,omitempty"`
+	// Formats maps workspaces to their own formats of times and dates
+	Formats map[string]Formats `toml:"formats,omitempty"`
+	// TimeFormat is the time layout of log entry times
+	TimeFormat string `toml:"time_format,omitempty"`
+	// DateFormat is the date layout of front matter dates
+	DateFormat string `toml:"date_format,omitempty"`
+	// Locale is the language of weekday and month names in dates
+	Locale string `toml:"locale,omitempty"`
+	// MaxInputSize is the size limit of log entries read from piped input
+	MaxInputSize string `toml:"max_input_size,omitempty"`
 }
 
 // Load initializes configuration to memory either with default values
-// or read from a `caplog.toml` configuration file if such exists.
+// or read from a `caplog.toml` configuration file if such exists. A local
+// workspace found from the working directory is selected as the current
+// workspace.
 func Load() error {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return err
 	}
 
-	return load(homeDir, &Config)
+	if err := load(homeDir, &Config); err != nil {
+		return err
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	return discover(workDir, &Config)
 }
 
 // Write writes given map structure to a configuration file
@@ -135,7 +424,7 @@ func load(homeDir string, config *config) error {
 
 	// Set defaults
 
-	config.CurrentWorkspace = "default"
+	config.CurrentWorkspace = defaultWorkspace
 	config.Editor = "vi"
 
 	configPath = findExistingConfigFile(homeDir)
@@ -156,15 +445,212 @@ func load(homeDir string, config *config) error {
 
 	// TODO: make this better, we need to append default workspace here
 	// as that one needs to be always available
-	config.Workspaces.Append("default", defaultPath)
+	config.Workspaces.Append(defaultWorkspace, defaultPath)
 
 	if exists := config.Workspaces.Has(config.CurrentWorkspace); !exists {
 		return ErrWorkspaceIsNotValid(config.CurrentWorkspace, config.Workspaces)
 	}
 
+	for _, t := range config.Layouts {
+		if _, err := layout.Parse(t); err != nil {
+			return err
+		}
+	}
+
+	for w, p := range config.Tags {
+		if err := p.validate(w); err != nil {
+			return err
+		}
+	}
+
+	if err := validateFormats(config); err != nil {
+		return err
+	}
+
+	if _, err := parseSize(config.MaxInputSize); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddWorkspace adds a workspace to the configuration file.
+func AddWorkspace(name string, path string) error {
+	return changeWorkspaces(func(c *config) error { return addWorkspace(c, name, path) })
+}
+
+// RemoveWorkspace removes the workspace from the configuration file. The
+// current workspace is changed to the default workspace when the current
+// workspace is removed.
+func RemoveWorkspace(name string) error {
+	return changeWorkspaces(func(c *config) error { return removeWorkspace(c, name) })
+}
+
+// RenameWorkspace renames the workspace in the configuration file.
+func RenameWorkspace(name string, newName string) error {
+	return changeWorkspaces(func(c *config) error { return renameWorkspace(c, name, newName) })
+}
+
+// changeWorkspaces applies the change to the loaded configuration, which
+// includes the default workspace, before changing the configuration file.
+func changeWorkspaces(change func(*config) error) error {
+	loaded := Config
+	loaded.Workspaces = append(Workspaces{}, Config.Workspaces...)
+
+	if err := change(&loaded); err != nil {
+		return err
+	}
+
+	if err := update(configPath, change); err != nil {
+		return err
+	}
+
+	if len(loaded.CurrentWorkspace) == 0 {
+		loaded.CurrentWorkspace = defaultWorkspace
+	}
+
+	Config = loaded
+
 	return nil
 }
 
+// ValidateWorkspaceName returns an error when the name cannot be used as the
+// name of a workspace.
+func ValidateWorkspaceName(name string) error {
+	if len(name) == 0 || strings.ContainsAny(name, ":,") {
+		return ErrInvalidWorkspaceName(name)
+	}
+
+	if name == defaultWorkspace {
+		return ErrDefaultWorkspace
+	}
+
+	return nil
+}
+
+func addWorkspace(c *config, name string, path string) error {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return err
+	}
+
+	if c.Workspaces.Has(name) {
+		return ErrWorkspaceExists(name)
+	}
+
+	c.Workspaces.Append(name, path)
+
+	return nil
+}
+
+func removeWorkspace(c *config, name string) error {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return err
+	}
+
+	i := c.Workspaces.index(name)
+	if i < 0 {
+		return ErrWorkspaceIsNotValid(name, c.Workspaces)
+	}
+
+	c.Workspaces = append(c.Workspaces[:i:i], c.Workspaces[i+1:]...)
+	delete(c.Encryption, name)
+	delete(c.Layouts, name)
+	delete(c.Tags, name)
+	delete(c.Formats, name)
+
+	if c.CurrentWorkspace == name {
+		c.CurrentWorkspace = ""
+	}
+
+	return nil
+}
+
+func renameWorkspace(c *config, name string, newName string) error {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return err
+	}
+
+	if err := ValidateWorkspaceName(newName); err != nil {
+		return err
+	}
+
+	i := c.Workspaces.index(name)
+	if i < 0 {
+		return ErrWorkspaceIsNotValid(name, c.Workspaces)
+	}
+
+	if c.Workspaces.Has(newName) {
+		return ErrWorkspaceExists(newName)
+	}
+
+	c.Workspaces[i].Name = newName
+
+	if e, ok := c.Encryption[name]; ok {
+		delete(c.Encryption, name)
+		c.Encryption[newName] = e
+	}
+
+	if l, ok := c.Layouts[name]; ok {
+		delete(c.Layouts, name)
+		c.Layouts[newName] = l
+	}
+
+	if p, ok := c.Tags[name]; ok {
+		delete(c.Tags, name)
+		c.Tags[newName] = p
+	}
+
+	if f, ok := c.Formats[name]; ok {
+		delete(c.Formats, name)
+		c.Formats[newName] = f
+	}
+
+	if c.CurrentWorkspace == name {
+		c.CurrentWorkspace = newName
+	}
+
+	return nil
+}
+
+// ExpandHome replaces the tilde in the path with the home directory.
+func ExpandHome(path string) string {
+	return replaceTilde(path, HomeDir)
+}
+
+// Keys returns the configuration keys, which can be set with Write.
+func Keys() []string {
+	return []string{CurrentWorkspaceKey, WorkspacesKey, EditorKey, SigningKeyKey, TimeFormatKey, DateFormatKey, LocaleKey, MaxInputSizeKey}
+}
+
+// Get returns the value of the configuration key in the same format it is
+// set with Write.
+func Get(k string) (string, error) {
+	switch k {
+	case CurrentWorkspaceKey:
+		return Config.CurrentWorkspace, nil
+	case WorkspacesKey:
+		ws := make([]string, len(Config.Workspaces))
+		for i, w := range Config.Workspaces {
+			ws[i] = fmt.Sprintf("%s:%s", w.Name, w.Path)
+		}
+		return strings.Join(ws, ","), nil
+	case EditorKey:
+		return Config.Editor, nil
+	case SigningKeyKey:
+		return Config.SigningKey, nil
+	case TimeFormatKey:
+		return TimeFormat(), nil
+	case DateFormatKey:
+		return DateFormat(), nil
+	case LocaleKey:
+		return Locale().Name, nil
+	case MaxInputSizeKey:
+		return strconv.FormatInt(MaxInputSize(), 10), nil
+	}
+
+	return "", ErrConfigKeyIsNotValid(k)
+}
+
 func mergeMapToConfig(c map[string]string, config *config) error {
 	for k, v := range c {
 		switch k {
@@ -188,17 +674,33 @@ func mergeMapToConfig(c map[string]string, config *config) error {
 
 			config.Workspaces = ws
 		case CurrentWorkspaceKey:
-			if exists := config.Workspaces.Has(v); !exists && v != "default" {
+			if exists := config.Workspaces.Has(v); !exists && v != defaultWorkspace {
 				return ErrWorkspaceIsNotValid(v, config.Workspaces)
 			}
 			config.CurrentWorkspace = v
 		case EditorKey:
 			config.Editor = v
+		case SigningKeyKey:
+			config.SigningKey = v
+		case TimeFormatKey:
+			config.TimeFormat = v
+		case DateFormatKey:
+			config.DateFormat = v
+		case LocaleKey:
+			config.Locale = v
+		case MaxInputSizeKey:
+			if _, err := parseSize(v); err != nil {
+				return err
+			}
+			config.MaxInputSize = v
 		default:
 			return ErrConfigKeyIsNotValid(k)
 		}
 	}
-	return nil
+
+	// Date format is validated with the locale, so they are validated
+	// together after both are set
+	return validateFormats(config)
 }
 
 func replaceTilde(s, r string) string {
@@ -206,6 +708,14 @@ func replaceTilde(s, r string) string {
 }
 
 func writeTo(configPath string, c map[string]string) error {
+	return update(configPath, func(localConfig *config) error {
+		return mergeMapToConfig(c, localConfig)
+	})
+}
+
+// update changes the configuration in the configuration file keeping the
+// other configuration values as they are.
+func update(configPath string, change func(*config) error) error {
 	var localConfig config
 	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
 		if err := toml.Unmarshal(configFile, &localConfig); err != nil {
@@ -213,10 +723,24 @@ func writeTo(configPath string, c map[string]string) error {
 		}
 	}
 
-	if err := mergeMapToConfig(c, &localConfig); err != nil {
+	if err := change(&localConfig); err != nil {
 		return err
 	}
 
+	// Empty maps are written as empty tables despite omitempty
+	if len(localConfig.Encryption) == 0 {
+		localConfig.Encryption = nil
+	}
+	if len(localConfig.Layouts) == 0 {
+		localConfig.Layouts = nil
+	}
+	if len(localConfig.Tags) == 0 {
+		localConfig.Tags = nil
+	}
+	if len(localConfig.Formats) == 0 {
+		localConfig.Formats = nil
+	}
+
 	config, err := toml.Marshal(&localConfig)
 	if err != nil {
 		return err
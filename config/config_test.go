@@ -91,6 +91,13 @@ func TestMergeMapToConfig(t *testing.T) {
 		{
 			input: map[string]string{WorkspacesKey: "test"},
 		},
+		{
+			input:    map[string]string{MaxInputSizeKey: "1M"},
+			expected: config{MaxInputSize: "1M"},
+		},
+		{
+			input: map[string]string{MaxInputSizeKey: "big"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -266,3 +273,304 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestAddWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		actual   config
+		expected config
+		err      bool
+	}{
+		{
+			name:     "test",
+			expected: config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}},
+		},
+		{
+			name:     "test",
+			actual:   config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}},
+			expected: config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}},
+			err:      true,
+		},
+		{
+			name: "default",
+			err:  true,
+		},
+		{
+			name: "",
+			err:  true,
+		},
+		{
+			name: "te:st",
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			err := addWorkspace(&tt.actual, tt.name, "~/test")
+
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, tt.actual) {
+				t.Fatalf("config did not match expected %+v, got %+v", tt.expected, tt.actual)
+			}
+		})
+	}
+}
+
+func TestRemoveWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		actual   config
+		expected config
+		err      bool
+	}{
+		{
+			name:     "test",
+			actual:   config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}, {Name: "test0", Path: "~/test0"}}},
+			expected: config{Workspaces: []Workspace{{Name: "test0", Path: "~/test0"}}},
+		},
+		{
+			name:     "test",
+			actual:   config{CurrentWorkspace: "test", Workspaces: []Workspace{{Name: "test", Path: "~/test"}}, Encryption: map[string]Encryption{"test": {}}},
+			expected: config{Workspaces: []Workspace{}, Encryption: map[string]Encryption{}},
+		},
+		{
+			name:     "test",
+			actual:   config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}, Formats: map[string]Formats{"test": {Locale: "fi"}}},
+			expected: config{Workspaces: []Workspace{}, Formats: map[string]Formats{}},
+		},
+		{
+			name: "test",
+			err:  true,
+		},
+		{
+			name:     "default",
+			actual:   config{Workspaces: []Workspace{{Name: "default", Path: "~/test"}}},
+			expected: config{Workspaces: []Workspace{{Name: "default", Path: "~/test"}}},
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			err := removeWorkspace(&tt.actual, tt.name)
+
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, tt.actual) {
+				t.Fatalf("config did not match expected %+v, got %+v", tt.expected, tt.actual)
+			}
+		})
+	}
+}
+
+func TestRenameWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		newName  string
+		actual   config
+		expected config
+		err      bool
+	}{
+		{
+			name:     "test",
+			newName:  "work",
+			actual:   config{CurrentWorkspace: "test", Workspaces: []Workspace{{Name: "test", Path: "~/test"}}, Encryption: map[string]Encryption{"test": {KeyFile: "key"}}},
+			expected: config{CurrentWorkspace: "work", Workspaces: []Workspace{{Name: "work", Path: "~/test"}}, Encryption: map[string]Encryption{"work": {KeyFile: "key"}}},
+		},
+		{
+			name:     "test",
+			newName:  "work",
+			actual:   config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}, Layouts: map[string]string{"test": "{{yyyy-mm-dd}}.md"}, Tags: map[string]TagPolicy{"test": {Case: TagCaseLower}}},
+			expected: config{Workspaces: []Workspace{{Name: "work", Path: "~/test"}}, Layouts: map[string]string{"work": "{{yyyy-mm-dd}}.md"}, Tags: map[string]TagPolicy{"work": {Case: TagCaseLower}}},
+		},
+		{
+			name:     "test",
+			newName:  "work",
+			actual:   config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}, Formats: map[string]Formats{"test": {TimeFormat: "3:04 PM"}}},
+			expected: config{Workspaces: []Workspace{{Name: "work", Path: "~/test"}}, Formats: map[string]Formats{"work": {TimeFormat: "3:04 PM"}}},
+		},
+		{
+			name:     "test",
+			newName:  "test0",
+			actual:   config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}, {Name: "test0", Path: "~/test0"}}},
+			expected: config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}, {Name: "test0", Path: "~/test0"}}},
+			err:      true,
+		},
+		{
+			name:    "test",
+			newName: "work",
+			err:     true,
+		},
+		{
+			name:     "test",
+			newName:  "default",
+			actual:   config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}},
+			expected: config{Workspaces: []Workspace{{Name: "test", Path: "~/test"}}},
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			err := renameWorkspace(&tt.actual, tt.name, tt.newName)
+
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, tt.actual) {
+				t.Fatalf("config did not match expected %+v, got %+v", tt.expected, tt.actual)
+			}
+		})
+	}
+}
+
+func TestSetLayout(t *testing.T) {
+	tests := []struct {
+		layouts  map[string]string
+		template string
+		expected map[string]string
+	}{
+		{
+			template: "{{year}}/{{yyyy-mm-dd}}.md",
+			expected: map[string]string{"test": "{{year}}/{{yyyy-mm-dd}}.md"},
+		},
+		{
+			layouts:  map[string]string{"test": "{{year}}/{{yyyy-mm-dd}}.md", "other": "{{yyyy-mm-dd}}.md"},
+			template: "{{dd-mm-yyyy}}.log.md",
+			expected: map[string]string{"other": "{{yyyy-mm-dd}}.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual := setLayout(tt.layouts, "test", tt.template)
+
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("layouts did not match expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWorkspaceFormats(t *testing.T) {
+	prev := Config
+	defer func() { Config = prev }()
+
+	Config = config{
+		CurrentWorkspace: "work",
+		TimeFormat:       "15:04:05",
+		DateFormat:       "2006-01-02",
+		Formats:          map[string]Formats{"work": {TimeFormat: "3:04 PM", Locale: "fi"}},
+	}
+
+	if f := TimeFormat(); f != "3:04 PM" {
+		t.Fatalf("expected time format of the workspace, got %s", f)
+	}
+
+	if f := DateFormat(); f != "2006-01-02" {
+		t.Fatalf("expected date format of the config, got %s", f)
+	}
+
+	if l := Locale(); l.Name != "fi" {
+		t.Fatalf("expected locale of the workspace, got %s", l.Name)
+	}
+
+	Config.CurrentWorkspace = "default"
+
+	if f := TimeFormat(); f != "15:04:05" {
+		t.Fatalf("expected time format of the config, got %s", f)
+	}
+}
+
+func TestValidateFormats(t *testing.T) {
+	tests := []struct {
+		config config
+		err    bool
+	}{
+		{},
+		{config: config{TimeFormat: "15:04:05"}},
+		{config: config{TimeFormat: "3:04PM"}},
+		{config: config{TimeFormat: "15"}, err: true},
+		{config: config{TimeFormat: "2006-01-02 15:04"}, err: true},
+		{config: config{TimeFormat: "15:04\t"}, err: true},
+		{config: config{DateFormat: "2006-01-02"}},
+		{config: config{DateFormat: "Monday 2. January 2006", Locale: "fi"}},
+		{config: config{DateFormat: "January 2006"}, err: true},
+		{config: config{Locale: "xx"}, err: true},
+		{config: config{Formats: map[string]Formats{"work": {TimeFormat: "3:04 PM", Locale: "fi"}}}},
+		{config: config{Formats: map[string]Formats{"work": {TimeFormat: "15"}}}, err: true},
+		// Date format of the config is validated with the locale of the workspace
+		{config: config{DateFormat: "Monday 2. January 2006", Formats: map[string]Formats{"work": {Locale: "xx"}}}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if err := validateFormats(&tt.config); tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateTagPolicy(t *testing.T) {
+	tests := []struct {
+		policy TagPolicy
+		err    bool
+	}{
+		{},
+		{policy: TagPolicy{Case: TagCaseKeep}},
+		{policy: TagPolicy{Case: TagCaseLower, Allowed: []string{"go", "parser"}}},
+		{policy: TagPolicy{Case: TagCaseExisting}},
+		{policy: TagPolicy{Case: "upper"}, err: true},
+		{policy: TagPolicy{Allowed: []string{"go", " "}}, err: true},
+		{policy: TagPolicy{Allowed: []string{"go,parser"}}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if err := tt.policy.validate("test"); tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size     string
+		expected int64
+		err      bool
+	}{
+		{expected: DefaultMaxInputSize},
+		{size: "65536", expected: 65536},
+		{size: "64K", expected: 64 << 10},
+		{size: "64kb", expected: 64 << 10},
+		{size: "64 KiB", expected: 64 << 10},
+		{size: "1M", expected: 1 << 20},
+		{size: "2MB", expected: 2 << 20},
+		{size: "0", err: true},
+		{size: "-1K", err: true},
+		{size: "1G", err: true},
+		{size: "K", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := parseSize(tt.size)
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+
+			if actual != tt.expected {
+				t.Fatalf("size did not match expected %d, got %d", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/erikjuhani/caplog/locale"
+)
+
+// Formats are the formats of log entry times and front matter dates and the
+// locale of the names in dates. Formats of a workspace override the formats
+// of the configuration, so workspaces can be read and written in their own
+// formats.
+type Formats struct {
+	// TimeFormat is the time layout of log entry times
+	TimeFormat string `toml:"time_format,omitempty"`
+	// DateFormat is the date layout of front matter dates
+	DateFormat string `toml:"date_format,omitempty"`
+	// Locale is the language of weekday and month names in dates
+	Locale string `toml:"locale,omitempty"`
+}
+
+// workspaceFormats returns the formats of the workspace with the formats of
+// the configuration in place of the formats the workspace does not set.
+func workspaceFormats(config *config, workspace string) Formats {
+	f := Formats{TimeFormat: config.TimeFormat, DateFormat: config.DateFormat, Locale: config.Locale}
+
+	w := config.Formats[workspace]
+	if len(w.TimeFormat) > 0 {
+		f.TimeFormat = w.TimeFormat
+	}
+	if len(w.DateFormat) > 0 {
+		f.DateFormat = w.DateFormat
+	}
+	if len(w.Locale) > 0 {
+		f.Locale = w.Locale
+	}
+
+	return f
+}
+
+func (f Formats) validate() error {
+	loc, err := locale.Lookup(f.Locale)
+	if err != nil {
+		return err
+	}
+
+	if len(f.TimeFormat) > 0 {
+		sample := time.Date(2000, 2, 2, 13, 45, 30, 0, time.UTC)
+
+		// Time is followed by a tab in the log file and it cannot include
+		// a date
+		formatted := sample.Format(f.TimeFormat)
+		t, err := time.Parse(f.TimeFormat, formatted)
+		if err != nil || strings.ContainsAny(formatted, "\t\n") || t.Hour() != 13 || t.Minute() != 45 || t.Year() != 0 || t.YearDay() != 1 {
+			return ErrInvalidTimeFormatF(f.TimeFormat)
+		}
+	}
+
+	if len(f.DateFormat) > 0 {
+		sample := time.Date(2022, 3, 15, 0, 0, 0, 0, time.UTC)
+
+		formatted := loc.Format(sample, f.DateFormat)
+		d, err := loc.Parse(f.DateFormat, formatted, time.UTC)
+		if err != nil || strings.Contains(formatted, "\n") || !d.Equal(sample) {
+			return ErrInvalidDateFormatF(f.DateFormat)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erikjuhani/caplog/layout"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	// Name of the file or directory marking a directory-local workspace
+	localMarker = ".caplog"
+	// Name of the config file when the marker is a directory
+	localConfigFilename = "config.toml"
+	// Path of the local workspace relative to the marker by default
+	defaultLocalPath = "capbook"
+)
+
+var (
+	ErrInvalidLocalConfigF     = func(path string, e error) error { return fmt.Errorf("invalid local config %s - %w", path, e) }
+	ErrLocalWorkspaceConflictF = func(workspace string, path string) error {
+		return fmt.Errorf("local workspace \"%s\" in %s conflicts with a configured workspace of the same name", workspace, path)
+	}
+	ErrLocalPathF = func(path string) error {
+		return fmt.Errorf("path \"%s\" of local workspace must be a sub-directory of the directory with the %s marker", path, localMarker)
+	}
+)
+
+// Reasons why the current workspace was chosen
+const (
+	SourceLocal   = "local"
+	SourceConfig  = "config"
+	SourceDefault = "default"
+)
+
+// Selection tells which workspace is the current workspace and why.
+type Selection struct {
+	Workspace string
+	// Source is one of SourceLocal, SourceConfig or SourceDefault
+	Source string
+	// Path is the local marker or the config file the workspace was read from
+	Path string
+	// Defined tells that the local config defines its own workspace instead of
+	// selecting a configured workspace
+	Defined bool
+}
+
+func (s Selection) String() string {
+	switch s.Source {
+	case SourceLocal:
+		return fmt.Sprintf("%s (local workspace from %s)", s.Workspace, s.Path)
+	case SourceConfig:
+		return fmt.Sprintf("%s (current_workspace in %s)", s.Workspace, s.Path)
+	default:
+		return fmt.Sprintf("%s (default workspace)", s.Workspace)
+	}
+}
+
+// Selected is the selection of the current workspace made when the
+// configuration was loaded
+var Selected Selection
+
+// localConfig is the content of a local marker file. The marker either names
+// a configured workspace or defines a workspace of its own with a path
+// relative to the directory of the marker.
+type localConfig struct {
+	Workspace string     `toml:"workspace,omitempty"`
+	Name      string     `toml:"name,omitempty"`
+	Path      string     `toml:"path,omitempty"`
+	Layout    string     `toml:"layout,omitempty"`
+	Tags      *TagPolicy `toml:"tags,omitempty"`
+	Formats   *Formats   `toml:"formats,omitempty"`
+}
+
+// findLocalConfig walks up from the directory to the root of the file system
+// and returns the path to the nearest local config. The local config is
+// either a `.caplog` file or a `config.toml` file in a `.caplog` directory.
+func findLocalConfig(dir string) (string, bool) {
+	for {
+		marker := filepath.Join(dir, localMarker)
+
+		if info, err := os.Stat(marker); err == nil {
+			if !info.IsDir() {
+				return marker, true
+			}
+
+			// A directory without a config, such as ~/.caplog holding the
+			// default workspace, is not a marker
+			if path := filepath.Join(marker, localConfigFilename); isFile(path) {
+				return path, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// discover selects the current workspace. A local workspace found from the
+// working directory takes precedence over the current_workspace in the config
+// file, which takes precedence over the default workspace.
+func discover(workDir string, config *config) error {
+	path, ok := findLocalConfig(workDir)
+	if !ok {
+		Selected = Selection{Workspace: config.CurrentWorkspace, Source: SourceDefault}
+		if config.CurrentWorkspace != defaultWorkspace {
+			Selected = Selection{Workspace: config.CurrentWorkspace, Source: SourceConfig, Path: configPath}
+		}
+
+		return nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var local localConfig
+	if err := toml.Unmarshal(b, &local); err != nil {
+		return ErrInvalidLocalConfigF(path, err)
+	}
+
+	name, err := applyLocalConfig(config, local, localRoot(path))
+	if err != nil {
+		return ErrInvalidLocalConfigF(path, err)
+	}
+
+	config.CurrentWorkspace = name
+	Selected = Selection{Workspace: name, Source: SourceLocal, Path: path, Defined: len(local.Workspace) == 0}
+
+	return nil
+}
+
+// localRoot returns the directory containing the local marker.
+func localRoot(path string) string {
+	if filepath.Base(path) == localConfigFilename {
+		return filepath.Dir(filepath.Dir(path))
+	}
+
+	return filepath.Dir(path)
+}
+
+// applyLocalConfig adds the workspace defined in the local config to the
+// workspaces and returns its name.
+func applyLocalConfig(config *config, local localConfig, root string) (string, error) {
+	if len(local.Workspace) > 0 {
+		if !config.Workspaces.Has(local.Workspace) {
+			return "", ErrWorkspaceIsNotValid(local.Workspace, config.Workspaces)
+		}
+
+		return local.Workspace, nil
+	}
+
+	name := local.Name
+	if len(name) == 0 {
+		name = filepath.Base(root)
+	}
+
+	path := local.Path
+	if len(path) == 0 {
+		path = defaultLocalPath
+	}
+
+	if !isLocalPath(path) {
+		return "", ErrLocalPathF(path)
+	}
+
+	path = filepath.Join(root, path)
+
+	if w, ok := config.Workspaces.Lookup(name); ok {
+		if replaceTilde(w.Path, HomeDir) != replaceTilde(path, HomeDir) {
+			return "", ErrLocalWorkspaceConflictF(name, root)
+		}
+
+		return name, nil
+	}
+
+	config.Workspaces.Append(name, path)
+
+	if len(local.Layout) > 0 {
+		if _, err := layout.Parse(local.Layout); err != nil {
+			return "", err
+		}
+
+		config.Layouts = setLayout(config.Layouts, name, local.Layout)
+	}
+
+	if local.Tags != nil {
+		if err := local.Tags.validate(name); err != nil {
+			return "", err
+		}
+
+		if config.Tags == nil {
+			config.Tags = map[string]TagPolicy{}
+		}
+		config.Tags[name] = *local.Tags
+	}
+
+	if local.Formats != nil {
+		if config.Formats == nil {
+			config.Formats = map[string]Formats{}
+		}
+		config.Formats[name] = *local.Formats
+
+		if err := workspaceFormats(config, name).validate(); err != nil {
+			return "", err
+		}
+	}
+
+	return name, nil
+}
+
+// isLocalPath reports whether the path is a sub-directory of the directory
+// with the local marker. The directory itself is not allowed, as the marker
+// file would take the place of the .caplog directory of the workspace.
+func isLocalPath(path string) bool {
+	if strings.HasPrefix(path, "~") || filepath.IsAbs(path) {
+		return false
+	}
+
+	first, _, _ := strings.Cut(filepath.ToSlash(filepath.Clean(path)), "/")
+
+	return first != "." && first != ".." && first != localMarker
+}
+
+// setLocalLayout changes the layout in the local config, which defines its
+// own workspace. Local configs selecting a configured workspace are not
+// changed.
+func setLocalLayout(path string, template string) (bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	var local localConfig
+	if err := toml.Unmarshal(b, &local); err != nil {
+		return false, ErrInvalidLocalConfigF(path, err)
+	}
+
+	if len(local.Workspace) > 0 {
+		return false, nil
+	}
+
+	local.Layout = template
+	if template == layout.Default {
+		local.Layout = ""
+	}
+
+	b, err = toml.Marshal(&local)
+	if err != nil {
+		return false, err
+	}
+
+	return true, os.WriteFile(path, b, 0644)
+}
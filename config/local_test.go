@@ -0,0 +1,216 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindLocalConfig(t *testing.T) {
+	root, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dirs := []string{"file/a/b", "dir/a", "empty/a", "dir/.caplog", "empty/.caplog"}
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := []string{"file/.caplog", "dir/.caplog/config.toml"}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(root, f), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{
+			dir:      "file",
+			expected: "file/.caplog",
+		},
+		{
+			dir:      "file/a/b",
+			expected: "file/.caplog",
+		},
+		{
+			dir:      "dir/a",
+			expected: "dir/.caplog/config.toml",
+		},
+		{
+			dir: "empty/a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, ok := findLocalConfig(filepath.Join(root, tt.dir))
+
+			expected := ""
+			if len(tt.expected) > 0 {
+				expected = filepath.Join(root, tt.expected)
+			}
+
+			if ok != (len(expected) > 0) || actual != expected {
+				t.Fatalf("local config did not match expected %s, got %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestApplyLocalConfig(t *testing.T) {
+	tests := []struct {
+		local        localConfig
+		workspaces   Workspaces
+		expected     string
+		expectedPath string
+		err          bool
+	}{
+		{
+			expected:     "project",
+			expectedPath: "/src/project/capbook",
+		},
+		{
+			local:        localConfig{Name: "notes", Path: "docs/notes"},
+			expected:     "notes",
+			expectedPath: "/src/project/docs/notes",
+		},
+		{
+			local:        localConfig{Path: "./notes/"},
+			expected:     "project",
+			expectedPath: "/src/project/notes",
+		},
+		{
+			local: localConfig{Path: "~/notes"},
+			err:   true,
+		},
+		{
+			local: localConfig{Path: "/notes"},
+			err:   true,
+		},
+		{
+			local: localConfig{Path: "docs/../../notes"},
+			err:   true,
+		},
+		{
+			local: localConfig{Path: "."},
+			err:   true,
+		},
+		{
+			local: localConfig{Path: ".caplog/notes"},
+			err:   true,
+		},
+		{
+			local:        localConfig{Workspace: "test"},
+			workspaces:   Workspaces{{Name: "test", Path: "~/test"}},
+			expected:     "test",
+			expectedPath: "~/test",
+		},
+		{
+			local: localConfig{Workspace: "test"},
+			err:   true,
+		},
+		{
+			workspaces:   Workspaces{{Name: "project", Path: "/src/project/capbook"}},
+			expected:     "project",
+			expectedPath: "/src/project/capbook",
+		},
+		{
+			workspaces: Workspaces{{Name: "project", Path: "~/project"}},
+			err:        true,
+		},
+		{
+			local:        localConfig{Formats: &Formats{TimeFormat: "3:04 PM"}},
+			expected:     "project",
+			expectedPath: "/src/project/capbook",
+		},
+		{
+			local: localConfig{Formats: &Formats{TimeFormat: "15"}},
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := config{Workspaces: tt.workspaces}
+
+			actual, err := applyLocalConfig(&c, tt.local, "/src/project")
+
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+
+			if tt.expected != actual {
+				t.Fatalf("workspace did not match expected %s, got %s", tt.expected, actual)
+			}
+
+			if w, _ := c.Workspaces.Lookup(actual); w.Path != tt.expectedPath {
+				t.Fatalf("workspace path did not match expected %s, got %s", tt.expectedPath, w.Path)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	project := filepath.Join(root, "project")
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(project, ".caplog"), []byte("name = 'eng'"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir      string
+		current  string
+		expected Selection
+	}{
+		{
+			dir:      root,
+			current:  "default",
+			expected: Selection{Workspace: "default", Source: SourceDefault},
+		},
+		{
+			dir:      root,
+			current:  "test",
+			expected: Selection{Workspace: "test", Source: SourceConfig, Path: configPath},
+		},
+		{
+			dir:      filepath.Join(project, "src"),
+			current:  "test",
+			expected: Selection{Workspace: "eng", Source: SourceLocal, Path: filepath.Join(project, ".caplog"), Defined: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := config{CurrentWorkspace: tt.current, Workspaces: Workspaces{{Name: "test", Path: "~/test"}}}
+
+			if err := discover(tt.dir, &c); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, Selected) {
+				t.Fatalf("selection did not match expected %+v, got %+v", tt.expected, Selected)
+			}
+
+			if c.CurrentWorkspace != tt.expected.Workspace {
+				t.Fatalf("current workspace did not match expected %s, got %s", tt.expected.Workspace, c.CurrentWorkspace)
+			}
+		})
+	}
+}
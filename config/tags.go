@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Case policies of the tags written in a workspace
+const (
+	// TagCaseKeep keeps tags as they are typed
+	TagCaseKeep = "keep"
+	// TagCaseLower writes tags in lower case
+	TagCaseLower = "lower"
+	// TagCaseExisting writes tags the same way as an existing tag differing
+	// only in case
+	TagCaseExisting = "existing"
+)
+
+var (
+	ErrInvalidTagCaseF = func(workspace string, c string) error {
+		return fmt.Errorf("invalid tag case \"%s\" in workspace \"%s\", expected %s, %s or %s", c, workspace, TagCaseKeep, TagCaseLower, TagCaseExisting)
+	}
+	ErrInvalidAllowedTagF = func(workspace string, tag string) error {
+		return fmt.Errorf("invalid allowed tag \"%s\" in workspace \"%s\", tags cannot be empty or contain commas", tag, workspace)
+	}
+)
+
+// TagPolicy normalizes and restricts the tags written in a workspace.
+type TagPolicy struct {
+	// Case is one of TagCaseKeep, TagCaseLower or TagCaseExisting
+	Case string `toml:"case,omitempty"`
+	// Allowed are the only tags accepted in the workspace when set
+	Allowed []string `toml:"allowed,omitempty"`
+}
+
+func (p TagPolicy) validate(workspace string) error {
+	switch p.Case {
+	case "", TagCaseKeep, TagCaseLower, TagCaseExisting:
+	default:
+		return ErrInvalidTagCaseF(workspace, p.Case)
+	}
+
+	for _, t := range p.Allowed {
+		if len(strings.TrimSpace(t)) == 0 || strings.ContainsAny(t, ",\r\n") {
+			return ErrInvalidAllowedTagF(workspace, t)
+		}
+	}
+
+	return nil
+}
+
+// WorkspaceTagPolicy returns the tag policy of the current workspace.
+func WorkspaceTagPolicy() TagPolicy {
+	p := Config.Tags[Config.CurrentWorkspace]
+	if len(p.Case) == 0 {
+		p.Case = TagCaseKeep
+	}
+
+	return p
+}
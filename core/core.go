@@ -7,16 +7,25 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/erikjuhani/caplog/config"
 	"github.com/erikjuhani/caplog/git"
+	"github.com/erikjuhani/caplog/locale"
 )
 
 type Meta struct {
 	Date time.Time
 	Page string
+	// Workspace is the workspace the log file was written in
+	Workspace string
+	// Authors are the git authors who wrote the log entries of the log file
+	Authors []string
+	// extra are the comments and the lines of front matter keys unknown to
+	// caplog, which are kept in the log file as they are
+	extra []string
 }
 
 func (m Meta) Location() string {
@@ -30,20 +39,7 @@ func (m Meta) Location() string {
 }
 
 func (m Meta) String() string {
-	// TODO: Refactor
-	if len(m.Page) == 0 {
-		return fmt.Sprintf(`---
-date: %s
----
-`, m.Date.Format(metaTimeLayout))
-	}
-
-	return fmt.Sprintf(`---
-date: %s
-
-page: %s
----
-`, m.Date.Format(metaTimeLayout), m.Page)
+	return formatFrontMatter(m, nil)
 }
 
 type Log struct {
@@ -51,15 +47,66 @@ type Log struct {
 	Data []string
 }
 
-const (
-	// TODO: Custom time layout
-	// WEEKDAY, MONTH DAY, YEAR
-	metaTimeLayout = "Monday, January 2, 2006"
-	timeFormat     = "15:04"
-	timeFileFormat = "02-01-2006"
-)
+const timeFileFormat = "02-01-2006"
+
+// timeFormats are the formats of log entry times read from log files in
+// addition to the configured format, so log files written with another
+// format can still be read
+var timeFormats = []string{
+	config.DefaultTimeFormat,
+	"15:04:05",
+	"3:04PM",
+	"3:04:05PM",
+	"3:04 PM",
+	"3:04:05 PM",
+	"3:04pm",
+	"3:04 pm",
+}
+
+// dateFormats are the formats of front matter dates read in addition to the
+// configured format
+var dateFormats = []string{
+	config.DefaultDateFormat,
+	"2006-01-02",
+}
+
+// formatDate formats the date with the configured date format and locale.
+func formatDate(date time.Time) string {
+	return config.Locale().Format(date, config.DateFormat())
+}
+
+// parseDate parses the date with the configured date format or any of the
+// other date formats in the configured locale or in English.
+func parseDate(s string) (time.Time, error) {
+	english, _ := locale.Lookup(locale.Default)
+
+	var err error
+	for _, f := range append([]string{config.DateFormat()}, dateFormats...) {
+		for _, l := range []locale.Locale{config.Locale(), english} {
+			var date time.Time
+			if date, err = l.Parse(f, s, time.Local); err == nil {
+				return date, nil
+			}
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// parseClock parses the time of day with the configured time format or any
+// of the other time formats.
+func parseClock(s string) (time.Time, bool) {
+	for _, f := range append([]string{config.TimeFormat()}, timeFormats...) {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
 
-func NewLog(meta Meta, data string, tags []string) Log {
+// NewLog returns the log entry with the tags and the other metadata fields.
+func NewLog(meta Meta, data string, tags []string, fields ...Field) Log {
 	l := Log{Meta: meta}
 
 	scanner := bufio.NewScanner(strings.NewReader(data))
@@ -68,8 +115,16 @@ func NewLog(meta Meta, data string, tags []string) Log {
 		l.Data = append(l.Data, scanner.Text())
 	}
 
+	var metadata []Field
 	if len(tags) > 0 {
-		l.Data = append(l.Data, fmt.Sprintf("\ntags: %s", strings.Join(tags, ", ")))
+		metadata = setField(metadata, Field{Key: tagsKey, Value: strings.Join(tags, ", ")})
+	}
+	for _, f := range fields {
+		metadata = setField(metadata, f)
+	}
+
+	if len(metadata) > 0 {
+		l.Data = append(l.Data, formatFields(metadata))
 	}
 
 	return l
@@ -80,42 +135,109 @@ func WriteLog(out io.Writer, l Log) error {
 		return errors.New("no data provided")
 	}
 
-	loc := l.Location()
-
-	if _, err := os.Stat(loc); os.IsNotExist(err) {
-		if err := os.MkdirAll(loc, os.ModePerm); err != nil {
-			return err
-		}
+	l, err := normalizeTags(l)
+	if err != nil {
+		return err
 	}
 
-	filename := logFilename(l)
-	filepath := fmt.Sprintf("%s/%s", loc, filename)
+	if err := os.MkdirAll(filepath.Dir(logPath(l)), os.ModePerm); err != nil {
+		return err
+	}
 
-	f, err := os.OpenFile(filepath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err == nil {
-		defer f.Close()
+	// Pages are sub-directories in the same git repository as the workspace
+	if err := initRepository(config.WorkspacePath()); err != nil {
+		return err
 	}
 
+	l.Meta = withAuthor(l.Meta)
+
+	filepath := logPath(l)
+
 	formattedLog := formatLog(l)
 
-	if _, ok := err.(*os.PathError); ok {
-		data := []byte(fmt.Sprintf("%s\n%s", l.Meta.String(), formattedLog))
-		if err := os.WriteFile(filepath, data, 0644); err != nil {
-			return err
-		}
+	logs, err := ReadLogFile(filepath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// The front matter sums up all log entries of the log file, so the log
+	// file is always written as a whole. Log entries written with an earlier
+	// time than the latest log entry are inserted in chronological order.
+	data := []byte(formatLogFile(insertLog(logs, l)))
+	if err := writeFile(filepath, data); err != nil {
+		return err
+	}
 
-		fmt.Fprintf(out, "wrote (%db) to %s", len(data), filepath)
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "wrote (%db) to %s", info.Size(), filepath)
+
+	// Encrypted log files are committed without revealing the log entry
+	if encrypted() {
+		return commitLog(filepath, commitMessage(logCommitPrefix, formattedLog), time.Time{}, l)
+	}
+
+	return commitLog(filepath, formattedLog, l.Date, l)
+}
 
-		return git.CommitSingleFile(filepath, formattedLog)
+// withAuthor adds the git author of the workspace to the authors of the log
+// file.
+func withAuthor(meta Meta) Meta {
+	author, err := git.Author(config.WorkspacePath())
+	if err != nil || len(author) == 0 {
+		return meta
 	}
 
-	if _, err := f.WriteString("\n" + formattedLog); err != nil {
+	meta.Authors = appendUnique(append([]string{}, meta.Authors...), author)
+
+	return meta
+}
+
+// commitLog signs the log entry written to the log file in path and commits
+// the log file with the log entry date as the author date.
+func commitLog(path string, msg string, date time.Time, l Log) error {
+	signatures, err := signLogs([]Log{l}, nil)
+	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(out, "wrote (%db) to %s", len("\n"+formattedLog), filepath)
+	return commit(msg, date, append([]string{path}, signatures...)...)
+}
+
+// initRepository initializes the git repository of the workspace. Workspace
+// defined in a local config is part of the enclosing repository, such as the
+// repository of the project, instead of a nested repository.
+func initRepository(root string) error {
+	if config.Selected.Defined && git.IsRepository(root) {
+		return nil
+	}
+
+	return git.Init(root)
+}
+
+// commit commits the written files in a single commit and brings the search
+// index up-to-date with the new commit. Author date is the current time when
+// the date is zero.
+func commit(msg string, date time.Time, paths ...string) error {
+	root := config.WorkspacePath()
+
+	files := make([]string, len(paths))
+	for i, path := range paths {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[i] = rel
+	}
+
+	if err := git.CommitFilesAt(root, files, msg, date); err != nil {
+		return err
+	}
 
-	return git.CommitSingleFile(filepath, formattedLog)
+	return updateIndex(root)
 }
 
 func openInEditor(filename string) error {
@@ -132,7 +254,9 @@ func openInEditor(filename string) error {
 	return command.Run()
 }
 
-func CaptureEditorInput() ([]byte, error) {
+// captureEditorInput opens the editor with the given content and returns
+// the content after the editor is closed.
+func captureEditorInput(content []byte) ([]byte, error) {
 	var input []byte
 
 	file, err := os.CreateTemp(os.TempDir(), "caplog")
@@ -143,6 +267,11 @@ func CaptureEditorInput() ([]byte, error) {
 	filename := file.Name()
 	defer os.Remove(filename)
 
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return input, err
+	}
+
 	if err := file.Close(); err != nil {
 		return input, err
 	}
@@ -164,11 +293,16 @@ func addPrefix(prefix string, s []string) []string {
 }
 
 func formatLog(log Log) string {
+	return formatLogAt(log, config.TimeFormat())
+}
+
+// formatLogAt formats the log entry with the time in the given layout.
+func formatLogAt(log Log, layout string) string {
 	if len(log.Data) == 0 {
 		return ""
 	}
 
-	ts := log.Date.Format(timeFormat)
+	ts := log.Date.Format(layout)
 	if len(log.Data) == 1 {
 		return fmt.Sprintf("%s\t%s\n", ts, log.Data[0])
 	}
@@ -177,10 +311,35 @@ func formatLog(log Log) string {
 		"%s\t%s\n%s\n",
 		ts,
 		log.Data[0],
-		strings.Join(addPrefix("	", log.Data[1:]), "\n"),
+		strings.Join(addPrefix("	", append([]string{}, log.Data[1:]...)), "\n"),
 	)
 }
 
+// formatLogFile formats the whole log file content with the front matter of
+// the log file followed by all the given logs.
+func formatLogFile(logs []Log) string {
+	if len(logs) == 0 {
+		return ""
+	}
+
+	var tags []string
+
+	formatted := make([]string, len(logs))
+	for i, l := range logs {
+		formatted[i] = formatLog(l)
+		tags = appendUnique(tags, l.Tags()...)
+	}
+
+	return fmt.Sprintf("%s\n%s", formatFrontMatter(fileMeta(logs), tags), strings.Join(formatted, "\n"))
+}
+
+// logFilename returns the path of the log file relative to the page
+// directory in the layout of the current workspace.
 func logFilename(log Log) string {
-	return fmt.Sprintf("%s.log.md", log.Date.Format(timeFileFormat))
+	return config.WorkspaceLayout().Path(log.Date)
+}
+
+// logPath returns the path of the log file of the log entry.
+func logPath(log Log) string {
+	return filepath.Join(log.Location(), logFilename(log))
 }
@@ -1,9 +1,16 @@
 package core
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
 )
 
 func TestCreateLog(t *testing.T) {
@@ -42,6 +49,46 @@ func TestCreateLog(t *testing.T) {
 	}
 }
 
+func TestWriteLogToExistingFile(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	path := filepath.Join(dir, "16-05-2022.log.md")
+
+	content := "---\n# Written by hand\ndate: Monday, May 16, 2022\nworkspace: other\ncssclass: wide\n---\n19:20\tWrote the parser\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := WriteLog(&out, NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed the parser", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Front matter keeps the workspace and the lines unknown to caplog
+	for _, line := range []string{"workspace: other\n", "# Written by hand\n", "cssclass: wide\n", "20:20\tReviewed the parser\n"} {
+		if !strings.Contains(string(b), line) {
+			t.Fatalf("expected log file to contain %q, got:\n%s", line, b)
+		}
+	}
+
+	if expected := fmt.Sprintf("wrote (%db) to %s", len(b), path); out.String() != expected {
+		t.Fatalf("expected output %q, got %q", expected, out.String())
+	}
+}
+
 func TestFormatLog(t *testing.T) {
 	testDate := time.Date(2022, 5, 14, 22, 34, 0, 0, time.UTC)
 
@@ -106,3 +153,45 @@ func TestLogFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestInitRepository(t *testing.T) {
+	prev := config.Selected
+	defer func() { config.Selected = prev }()
+
+	tests := []struct {
+		defined  bool
+		expected bool
+	}{
+		{defined: true, expected: false},
+		{defined: false, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			project, err := os.MkdirTemp("", "caplog")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(project)
+
+			if err := git.Init(project); err != nil {
+				t.Fatal(err)
+			}
+
+			root := filepath.Join(project, "capbook")
+			if err := os.MkdirAll(root, os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+
+			config.Selected = config.Selection{Source: config.SourceLocal, Defined: tt.defined}
+
+			if err := initRepository(root); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := os.Stat(filepath.Join(root, ".git")); tt.expected != (err == nil) {
+				t.Fatalf("expected nested repository %t, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDateTimeF = func(s string) error {
+	return fmt.Errorf("\"%s\" is not a valid date and time (ex. 2022-05-16 19:20, yesterday 19:20 or 19:20)", s)
+}
+
+// ParseDate parses a date given either in ISO 8601 or in the log filename
+// format.
+func ParseDate(s string) (time.Time, error) {
+	date, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err == nil {
+		return date, nil
+	}
+
+	if date, err := time.ParseInLocation(timeFileFormat, s, time.Local); err == nil {
+		return date, nil
+	}
+
+	return date, err
+}
+
+// ParseDateTime parses a date followed by an optional time of day relative to
+// now. The date can be a date accepted by ParseDate, a date in the configured
+// date format, today, yesterday or a weekday meaning the latest such day
+// before today. Time of day is the current time if not given and the date is
+// today if only the time of day is given. Both may contain spaces, such as
+// the time of day in the "3:04 PM" format.
+func ParseDateTime(s string, now time.Time) (time.Time, error) {
+	fields := strings.Fields(s)
+
+	// Input is split between the date and the time of day at each space until
+	// both parts are valid
+	for i := 0; i <= len(fields) && len(fields) > 0; i++ {
+		date, clock := now, now.Truncate(time.Minute)
+
+		if i > 0 {
+			d, err := parseRelativeDate(strings.Join(fields[:i], " "), now)
+			if err != nil {
+				continue
+			}
+			date = d
+		}
+
+		if i < len(fields) {
+			c, ok := parseClock(strings.Join(fields[i:], " "))
+			if !ok {
+				continue
+			}
+			clock = c
+		}
+
+		return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local), nil
+	}
+
+	return time.Time{}, ErrInvalidDateTimeF(s)
+}
+
+func parseRelativeDate(s string, now time.Time) (time.Time, error) {
+	today := day(now)
+
+	switch strings.ToLower(s) {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	for d := 1; d <= 7; d++ {
+		date := today.AddDate(0, 0, -d)
+		if strings.EqualFold(date.Weekday().String(), s) {
+			return date, nil
+		}
+	}
+
+	if date, err := ParseDate(s); err == nil {
+		return date, nil
+	}
+
+	return parseDate(s)
+}
+
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
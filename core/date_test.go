@@ -0,0 +1,99 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+func TestParseDateTime(t *testing.T) {
+	// Sunday
+	now := time.Date(2022, 5, 22, 12, 34, 56, 0, time.Local)
+
+	tests := []struct {
+		input      string
+		expected   time.Time
+		expectsErr bool
+	}{
+		{
+			input:    "16:30",
+			expected: time.Date(2022, 5, 22, 16, 30, 0, 0, time.Local),
+		},
+		{
+			input:    "yesterday 16:30",
+			expected: time.Date(2022, 5, 21, 16, 30, 0, 0, time.Local),
+		},
+		{
+			input:    "yesterday",
+			expected: time.Date(2022, 5, 21, 12, 34, 0, 0, time.Local),
+		},
+		{
+			input:    "Monday 09:00",
+			expected: time.Date(2022, 5, 16, 9, 0, 0, 0, time.Local),
+		},
+		{
+			input:    "sunday",
+			expected: time.Date(2022, 5, 15, 12, 34, 0, 0, time.Local),
+		},
+		{
+			input:    "2022-05-14 22:34",
+			expected: time.Date(2022, 5, 14, 22, 34, 0, 0, time.Local),
+		},
+		{
+			input:    "14-05-2022",
+			expected: time.Date(2022, 5, 14, 12, 34, 0, 0, time.Local),
+		},
+		{
+			input:      "",
+			expectsErr: true,
+		},
+		{
+			input:      "yesterday 25:00",
+			expectsErr: true,
+		},
+		{
+			input:      "tomorrow",
+			expectsErr: true,
+		},
+		{
+			input:      "2022-05-14 22:34 extra",
+			expectsErr: true,
+		},
+		{
+			input:    "yesterday 4:30 PM",
+			expected: time.Date(2022, 5, 21, 16, 30, 0, 0, time.Local),
+		},
+		{
+			input:    "4:30 PM",
+			expected: time.Date(2022, 5, 22, 16, 30, 0, 0, time.Local),
+		},
+		{
+			// Time and date in the configured formats
+			input:    "Saturday 14. May 2022 22.34 h",
+			expected: time.Date(2022, 5, 14, 22, 34, 0, 0, time.Local),
+		},
+		{
+			input:    "yesterday 16.30 h",
+			expected: time.Date(2022, 5, 21, 16, 30, 0, 0, time.Local),
+		},
+	}
+
+	defer testConfig(t, os.TempDir(), "")()
+	config.Config.TimeFormat = "15.04 h"
+	config.Config.DateFormat = "Monday 2. January 2006"
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			actual, err := ParseDateTime(tt.input, now)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if !tt.expected.Equal(actual) {
+				t.Fatalf("expected date %s did not match actual date %s", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoLogs          = errors.New("no log entries found")
+	ErrNoChanges       = errors.New("no changes made to log entry")
+	ErrLogNotFoundF    = func(date time.Time) error { return fmt.Errorf("no log entry found at %s", date.Format("2006-01-02 15:04")) }
+	ErrNthLogNotFoundF = func(date time.Time, n int, count int) error {
+		return fmt.Errorf("no log entry %d found at %s, only %d written on that minute", n, date.Format("2006-01-02 15:04"), count)
+	}
+	ErrEmptyLogEntry = errors.New("log entry cannot be empty")
+)
+
+// logRef refers to a single log entry in a log file
+type logRef struct {
+	path string
+	logs []Log
+	i    int
+}
+
+func (r logRef) log() Log {
+	return r.logs[r.i]
+}
+
+// ref returns a short reference to the log entry used in commit messages
+func (r logRef) ref() string {
+	return fmt.Sprintf("%s %s", logKey(r.log()), r.log().Summary())
+}
+
+// findLog finds the nth log entry written on the same minute as the date in
+// the given page of the current workspace. Log entries written on the same
+// minute are numbered from 1 in the order of the log file.
+func findLog(page string, date time.Time, n int) (logRef, error) {
+	l := Log{Meta: Meta{Date: date, Page: page}}
+	path := logPath(l)
+
+	logs, err := ReadLogFile(path)
+	if os.IsNotExist(err) {
+		return logRef{}, ErrLogNotFoundF(date)
+	}
+	if err != nil {
+		return logRef{}, err
+	}
+
+	var count int
+	for i, ll := range logs {
+		if !ll.Date.Truncate(time.Minute).Equal(date.Truncate(time.Minute)) {
+			continue
+		}
+
+		if count++; count == n {
+			return logRef{path: path, logs: logs, i: i}, nil
+		}
+	}
+
+	if count > 0 {
+		return logRef{}, ErrNthLogNotFoundF(date, n, count)
+	}
+
+	return logRef{}, ErrLogNotFoundF(date)
+}
+
+// findLastLog finds the latest log entry in the given page of the current
+// workspace. The log entry is looked up from the log file of the search result,
+// so the last of the log entries written on the same minute is found.
+func findLastLog(page string) (logRef, error) {
+	results, err := Search(Query{Pages: []string{page}, Limit: 1})
+	if err != nil {
+		return logRef{}, err
+	}
+
+	if len(results) == 0 {
+		return logRef{}, ErrNoLogs
+	}
+
+	logs, err := ReadLogFile(results[0].Path)
+	if err != nil {
+		return logRef{}, err
+	}
+
+	ref := logRef{path: results[0].Path, logs: logs, i: -1}
+	for i, l := range logs {
+		if ref.i < 0 || !l.Date.Before(logs[ref.i].Date) {
+			ref.i = i
+		}
+	}
+
+	if ref.i < 0 {
+		return logRef{}, ErrNoLogs
+	}
+
+	return ref, nil
+}
+
+// EditLog opens the nth log entry written at the given date in the editor and
+// replaces the log entry with the edited content keeping the time and tags
+// of the log entry. The latest log entry is edited when date is zero.
+func EditLog(out io.Writer, page string, date time.Time, n int) error {
+	var (
+		ref logRef
+		err error
+	)
+
+	if date.IsZero() {
+		ref, err = findLastLog(page)
+	} else {
+		ref, err = findLog(page, date, n)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	original := ref.log()
+
+	content := original.Data
+	if i := original.metadataIndex(); i >= 0 {
+		content = content[:i]
+	}
+
+	input, err := captureEditorInput([]byte(strings.Join(content, "\n") + "\n"))
+	if err != nil {
+		return err
+	}
+
+	if len(strings.TrimSpace(string(input))) == 0 {
+		return ErrEmptyLogEntry
+	}
+
+	edited, err := normalizeTags(NewLog(withAuthor(original.Meta), string(input), nil, original.Fields()...))
+	if err != nil {
+		return err
+	}
+
+	if formatLog(edited) == formatLog(original) {
+		return ErrNoChanges
+	}
+
+	msg := commitMessage(editCommitPrefix, fmt.Sprintf("%s\n\n%s", ref.ref(), formatLog(edited)))
+
+	ref.logs[ref.i] = edited
+
+	data := []byte(formatLogFile(ref.logs))
+	if err := writeFile(ref.path, data); err != nil {
+		return err
+	}
+
+	signatures, err := signLogs([]Log{edited}, []Log{original})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "edited log entry in %s", ref.path)
+
+	return commit(msg, time.Time{}, append([]string{ref.path}, signatures...)...)
+}
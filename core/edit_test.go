@@ -0,0 +1,167 @@
+package core
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+// testConfig sets the workspace and the editor used in the test and returns
+// a function restoring the previous configuration.
+func testConfig(t *testing.T, dir string, editorScript string) func() {
+	prev := config.Config
+
+	config.Config.CurrentWorkspace = "test"
+	config.Config.Workspaces = config.Workspaces{{Name: "test", Path: dir}}
+
+	if len(editorScript) > 0 {
+		editor := dir + "/.editor"
+		if err := os.WriteFile(editor, []byte("#!/bin/sh\n"+editorScript+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		config.Config.Editor = editor
+	}
+
+	return func() { config.Config = prev }
+}
+
+func TestEditLog(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Frist entry\n\nWith body", []string{"tag0"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Second entry", nil),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	// Editing adds the author of the edit to the front matter
+	author, err := git.Author(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		editor     string
+		date       time.Time
+		expected   []Log
+		expectsErr bool
+	}{
+		{
+			editor: `sed -i 's/Frist/First/' "$1"`,
+			date:   testDate,
+			expected: []Log{
+				NewLog(Meta{Date: testDate, Authors: []string{author}}, "First entry\n\nWith body", []string{"tag0"}),
+				NewLog(Meta{Date: testDate.Add(time.Hour)}, "Second entry", nil),
+			},
+		},
+		{
+			editor: `echo "Last entry" > "$1"`,
+			expected: []Log{
+				NewLog(Meta{Date: testDate, Authors: []string{author}}, "First entry\n\nWith body", []string{"tag0"}),
+				NewLog(Meta{Date: testDate.Add(time.Hour)}, "Last entry", nil),
+			},
+		},
+		{
+			editor:     "true",
+			expectsErr: true,
+		},
+		{
+			editor:     `echo > "$1"`,
+			expectsErr: true,
+		},
+		{
+			editor:     "true",
+			date:       testDate.Add(time.Minute),
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			defer testConfig(t, dir, tt.editor)()
+
+			err := EditLog(io.Discard, "", tt.date, 1)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr {
+				return
+			}
+
+			actual, err := ReadLogFile(dir + "/16-05-2022.log.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if formatLogFile(tt.expected) != formatLogFile(actual) {
+				t.Fatalf("expected log file:\n%s\ndid not match actual log file:\n%s", formatLogFile(tt.expected), formatLogFile(actual))
+			}
+
+			commits, err := git.FileCommits(dir + "/16-05-2022.log.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(commits) == 0 || commits[0].Message[:6] != "edit: " {
+				t.Fatalf("expected edit to be committed, got %v", commits)
+			}
+		})
+	}
+}
+
+func TestFindLog(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "First entry", nil),
+		NewLog(Meta{Date: testDate}, "Second entry", nil),
+		NewLog(Meta{Date: testDate.Add(-time.Hour)}, "Earlier entry", nil),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	tests := []struct {
+		date       time.Time
+		n          int
+		expected   string
+		expectsErr bool
+	}{
+		{date: testDate, n: 1, expected: "First entry"},
+		{date: testDate, n: 2, expected: "Second entry"},
+		{date: testDate.Add(-time.Hour), n: 1, expected: "Earlier entry"},
+		{date: testDate, n: 3, expectsErr: true},
+		{date: testDate.Add(time.Minute), n: 1, expectsErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			ref, err := findLog("", tt.date, tt.n)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if !tt.expectsErr && ref.log().Summary() != tt.expected {
+				t.Fatalf("expected log entry %q, got %q", tt.expected, ref.log().Summary())
+			}
+		})
+	}
+
+	ref, err := findLastLog("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref.log().Summary() != "Second entry" {
+		t.Fatalf("expected latest log entry %q, got %q", "Second entry", ref.log().Summary())
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+var ErrEmptyEntry = errors.New("aborted: empty entry")
+
+// helpLatest is the number of latest log entries of the day listed in the
+// editor help
+const helpLatest = 5
+
+// scissors separates the log entry from the help in the editor. Everything
+// below it is removed, like with the scissors cleanup mode of git commit.
+const scissors = "# ------------------------ >8 ------------------------"
+
+// CaptureEditorInput opens the editor pre-filled with the content followed by
+// commented help about the log entry, like git does with commit messages. The
+// help is removed from the returned input. Pre-filled content saved without
+// changes is not a log entry.
+func CaptureEditorInput(meta Meta, tags []string, content string) ([]byte, error) {
+	help := editorHelp(meta, tags)
+
+	input, err := captureEditorInput([]byte(content + "\n" + help))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := stripHelp(string(input))
+
+	if len(strings.TrimSpace(entry)) == 0 {
+		return nil, ErrEmptyEntry
+	}
+
+	if len(content) > 0 && entry == strings.Trim(content, "\n") {
+		return nil, ErrNoChanges
+	}
+
+	return []byte(entry), nil
+}
+
+// editorHelp returns the commented help lines shown below the log entry in
+// the editor with the latest log entries written on the same day.
+func editorHelp(meta Meta, tags []string) string {
+	lines := []string{
+		"Do not modify or remove the line above.",
+		"Write the log entry above it, the first line is the summary.",
+		"Everything below it is removed and an empty log entry is not written.",
+		"",
+		fmt.Sprintf("Workspace: %s", config.Config.CurrentWorkspace),
+	}
+
+	if len(meta.Page) > 0 {
+		lines = append(lines, fmt.Sprintf("Page: %s", meta.Page))
+	}
+
+	if len(tags) > 0 {
+		lines = append(lines, fmt.Sprintf("Tags: %s", strings.Join(tags, ", ")))
+	}
+
+	lines = append(lines, "")
+
+	// Help is shown without the log entries when the log file cannot be read
+	logs, _ := ReadLogFile(logPath(Log{Meta: Meta{Date: meta.Date, Page: meta.Page}}))
+	if len(logs) == 0 {
+		lines = append(lines, fmt.Sprintf("No log entries on %s", formatDate(meta.Date)))
+	} else {
+		lines = append(lines, fmt.Sprintf("Latest log entries on %s:", formatDate(meta.Date)))
+		if len(logs) > helpLatest {
+			logs = logs[len(logs)-helpLatest:]
+		}
+		for _, l := range logs {
+			lines = append(lines, fmt.Sprintf("  %s  %s", l.Date.Format(config.TimeFormat()), l.Summary()))
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("# "+line, " ")
+	}
+
+	return scissors + "\n" + strings.Join(lines, "\n") + "\n"
+}
+
+// stripHelp removes the help below the scissors line from the input and the
+// empty lines around the log entry. Lines above the scissors line are kept as
+// they are, so markdown headings and comments written in the log entry are
+// not removed.
+func stripHelp(input string) string {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+
+	for i, line := range lines {
+		if strings.TrimRight(line, " ") == scissors {
+			lines = lines[:i]
+			break
+		}
+	}
+
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCaptureEditorInput(t *testing.T) {
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	meta := Meta{Date: time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)}
+
+	tests := []struct {
+		editorScript string
+		content      string
+		expected     string
+		err          error
+	}{
+		{
+			editorScript: `sed -i '1s/^/Wrote the parser/' "$1"`,
+			expected:     "Wrote the parser",
+		},
+		{
+			editorScript: `sed -i '1s/^/Wrote the parser\n\n# Parser\n#parser/' "$1"`,
+			expected:     "Wrote the parser\n\n# Parser\n#parser",
+		},
+		{
+			// Lines matching lines of the help are kept above the scissors line
+			editorScript: `sed -i '1s/^/Wrote the parser\n#\n# Workspace: test/' "$1"`,
+			expected:     "Wrote the parser\n#\n# Workspace: test",
+		},
+		{
+			editorScript: `printf '\n\nWrote the parser\n\n' > "$1"`,
+			expected:     "Wrote the parser",
+		},
+		{
+			editorScript: `sed -i 's/Today:/Today: parser/' "$1"`,
+			content:      "Standup\nToday:\n",
+			expected:     "Standup\nToday: parser",
+		},
+		{
+			editorScript: "true",
+			err:          ErrEmptyEntry,
+		},
+		{
+			editorScript: `sed -i '1s/^/   /' "$1"`,
+			err:          ErrEmptyEntry,
+		},
+		{
+			editorScript: "true",
+			content:      "Standup\nToday:\n",
+			err:          ErrNoChanges,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			defer testConfig(t, dir, tt.editorScript)()
+
+			actual, err := CaptureEditorInput(meta, []string{"go"}, tt.content)
+			if err != tt.err {
+				t.Fatalf("expected error %v, got %v", tt.err, err)
+			}
+
+			if tt.expected != string(actual) {
+				t.Fatalf("expected input %q did not equal to actual input %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEditorHelp(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	var logs []Log
+	for i := 0; i < 6; i++ {
+		logs = append(logs, NewLog(Meta{Date: testDate.Add(time.Duration(i) * time.Minute), Page: "work"}, "Log entry\nwith body", nil))
+	}
+
+	dir := testWorkspace(t, logs...)
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	tests := []struct {
+		meta     Meta
+		tags     []string
+		expected string
+	}{
+		{
+			meta: Meta{Date: testDate.AddDate(0, 0, 1)},
+			expected: `# ------------------------ >8 ------------------------
+# Do not modify or remove the line above.
+# Write the log entry above it, the first line is the summary.
+# Everything below it is removed and an empty log entry is not written.
+#
+# Workspace: test
+#
+# No log entries on Tuesday, May 17, 2022
+`,
+		},
+		{
+			meta: Meta{Date: testDate, Page: "work"},
+			tags: []string{"go", "parser"},
+			expected: `# ------------------------ >8 ------------------------
+# Do not modify or remove the line above.
+# Write the log entry above it, the first line is the summary.
+# Everything below it is removed and an empty log entry is not written.
+#
+# Workspace: test
+# Page: work
+# Tags: go, parser
+#
+# Latest log entries on Monday, May 16, 2022:
+#   19:21  Log entry
+#   19:22  Log entry
+#   19:23  Log entry
+#   19:24  Log entry
+#   19:25  Log entry
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual := editorHelp(tt.meta, tt.tags)
+
+			if tt.expected != actual {
+				t.Fatalf("expected help %q did not equal to actual help %q", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/crypt"
+	"github.com/erikjuhani/caplog/git"
+)
+
+const (
+	// Commit message used in encrypted workspaces instead of the log entry
+	encryptedCommitMessage = "encrypted"
+
+	// Salt for new encrypted log files is stored in the git directory of
+	// the workspace. Every encrypted log file carries its own salt, so the
+	// salt is not needed for decrypting.
+	saltFilename = "caplog-salt"
+)
+
+var ErrIndexDisabled = errors.New("encrypted workspaces are not indexed")
+
+var (
+	keyring           *crypt.Keyring
+	keyringPassphrase string
+)
+
+// encrypted reports whether the current workspace is encrypted.
+func encrypted() bool {
+	_, ok := config.WorkspaceEncryption()
+	return ok
+}
+
+// commitMessage returns the commit message with the prefix, which does not
+// reveal the log entry in encrypted workspaces.
+func commitMessage(prefix string, msg string) string {
+	if encrypted() {
+		return prefix + encryptedCommitMessage
+	}
+
+	return prefix + msg
+}
+
+// workspaceKeyring returns the keyring for the passphrase of the current
+// workspace. The keyring is reused as long as the passphrase stays the same.
+func workspaceKeyring() (*crypt.Keyring, error) {
+	e, _ := config.WorkspaceEncryption()
+
+	passphrase, err := e.Passphrase(config.Config.CurrentWorkspace)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyring != nil && keyringPassphrase == string(passphrase) {
+		return keyring, nil
+	}
+
+	k, err := crypt.NewKeyring(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, keyringPassphrase = k, string(passphrase)
+
+	return keyring, nil
+}
+
+// workspaceSalt returns the salt used for new encrypted log files in the
+// current workspace, a new salt is created when the workspace has none.
+func workspaceSalt() ([]byte, error) {
+	gitDir, err := git.Dir(config.WorkspacePath())
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(gitDir, saltFilename)
+
+	if b, err := os.ReadFile(path); err == nil {
+		if salt, err := hex.DecodeString(strings.TrimSpace(string(b))); err == nil && len(salt) == crypt.SaltSize {
+			return salt, nil
+		}
+	}
+
+	salt, err := crypt.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	return salt, os.WriteFile(path, []byte(hex.EncodeToString(salt)+"\n"), 0600)
+}
+
+// isEncryptedFile reports whether the file in the given path is encrypted.
+func isEncryptedFile(path string) bool {
+	b, err := os.ReadFile(path)
+
+	return err == nil && crypt.IsEncrypted(b)
+}
+
+// readFile reads the log file in the given path and decrypts it when the
+// log file is encrypted.
+func readFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(b)
+}
+
+// decrypt decrypts the content of a log file when it is encrypted.
+func decrypt(b []byte) ([]byte, error) {
+	if !crypt.IsEncrypted(b) {
+		return b, nil
+	}
+
+	k, err := workspaceKeyring()
+	if err != nil {
+		return nil, err
+	}
+
+	return k.Decrypt(b)
+}
+
+// writeFile writes the log file to the given path and encrypts it when the
+// current workspace is encrypted.
+func writeFile(path string, data []byte) error {
+	if !encrypted() {
+		return os.WriteFile(path, data, 0644)
+	}
+
+	k, err := workspaceKeyring()
+	if err != nil {
+		return err
+	}
+
+	salt, err := workspaceSalt()
+	if err != nil {
+		return err
+	}
+
+	b, err := k.Encrypt(data, salt)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0644)
+}
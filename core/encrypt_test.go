@@ -0,0 +1,99 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+func TestEncryptedWorkspace(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+	config.Config.Encryption = map[string]config.Encryption{"test": {}}
+
+	t.Setenv(config.PassphraseEnv, "passphrase")
+
+	logs := []Log{
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Secret meeting notes", []string{"secret"}),
+		NewLog(Meta{Date: testDate}, "Earlier secret", nil),
+	}
+
+	for _, l := range logs {
+		if err := WriteLog(io.Discard, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, "16-05-2022.log.md")
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(b), "secret") {
+		t.Fatalf("expected log file to be encrypted, got %s", b)
+	}
+
+	actual, err := ReadLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	author, err := git.Author(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Log{logs[1], logs[0]}
+	expected[0].Authors = []string{author}
+	if formatLogFile(expected) != formatLogFile(actual) {
+		t.Fatalf("expected log file:\n%s\ndid not match actual log file:\n%s", formatLogFile(expected), formatLogFile(actual))
+	}
+
+	commits, err := git.Commits(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range commits {
+		if c.Message != logCommitPrefix+encryptedCommitMessage {
+			t.Fatalf("expected commit message to not reveal the log entry, got %q", c.Message)
+		}
+	}
+
+	// Commits of the log entries are found from the encrypted log files
+	expectedCommits := map[entryKey]string{{"19:20", 0}: commits[0].Hash, {"20:20", 0}: commits[1].Hash}
+	if actual := entryCommits(path); !reflect.DeepEqual(expectedCommits, actual) {
+		t.Fatalf("expected commits %v did not equal to actual commits %v", expectedCommits, actual)
+	}
+
+	results, err := Search(Query{Terms: []string{"meeting"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Summary() != "Secret meeting notes" {
+		t.Fatalf("expected to find the encrypted log entry, got %v", results)
+	}
+
+	t.Setenv(config.PassphraseEnv, "wrong")
+
+	if _, err := ReadLogFile(path); err == nil {
+		t.Fatal("expected reading with a wrong passphrase to fail")
+	}
+}
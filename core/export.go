@@ -0,0 +1,251 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+// Supported export formats
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Number of the most recent log entries in feeds unless limited otherwise
+const defaultFeedLimit = 20
+
+var ErrUnsupportedFormatF = func(format string, formats ...string) error {
+	return fmt.Errorf("\"%s\" is not a supported format, supported formats are: %v", format, formats)
+}
+
+// Entry is the exported representation of a log entry.
+type Entry struct {
+	Workspace string    `json:"workspace"`
+	Page      string    `json:"page"`
+	Timestamp time.Time `json:"timestamp"`
+	Summary   string    `json:"summary"`
+	Body      string    `json:"body"`
+	Tags      []string  `json:"tags"`
+	// Fields are the metadata fields of the log entry other than tags
+	Fields map[string]string `json:"fields,omitempty"`
+	// File is the log file path relative to the workspace
+	File string `json:"file"`
+	// Commit is the hash of the commit that added the log entry
+	Commit string `json:"commit,omitempty"`
+	// ordinal is the order of the log entry among the log entries written on
+	// the same minute in the log file
+	ordinal int
+}
+
+// Export writes the log entries matching the query from the current
+// workspace in the given format.
+func Export(out io.Writer, q Query, format string) error {
+	switch format {
+	case FormatJSON, FormatNDJSON, FormatAtom, FormatRSS:
+	default:
+		return ErrUnsupportedFormatF(format, FormatJSON, FormatNDJSON, FormatAtom, FormatRSS)
+	}
+
+	if (format == FormatAtom || format == FormatRSS) && q.Limit == 0 {
+		q.Limit = defaultFeedLimit
+	}
+
+	results, err := Search(q)
+	if err != nil {
+		return err
+	}
+
+	workspace, root := config.Config.CurrentWorkspace, config.WorkspacePath()
+
+	entries, err := exportEntries(workspace, root, results)
+	if err != nil {
+		return err
+	}
+
+	if format == FormatAtom || format == FormatRSS {
+		return writeFeed(out, newFeed(workspace, root, q.Pages, entries), format)
+	}
+
+	enc := json.NewEncoder(out)
+
+	if format == FormatNDJSON {
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(entries)
+}
+
+func exportEntries(workspace string, root string, results []Result) ([]Entry, error) {
+	entries := []Entry{}
+	commits := map[string]map[entryKey]string{}
+	logs := map[string][]Log{}
+
+	for _, r := range results {
+		file, err := filepath.Rel(root, r.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := commits[r.Path]; !ok {
+			commits[r.Path] = entryCommits(r.Path)
+		}
+
+		if _, ok := logs[r.Path]; !ok {
+			if logs[r.Path], err = ReadLogFile(r.Path); err != nil {
+				return nil, err
+			}
+		}
+
+		key := newEntryKey(r.Log, logs[r.Path])
+
+		tags := r.Tags()
+		if tags == nil {
+			tags = []string{}
+		}
+
+		var fields map[string]string
+		for _, f := range r.Fields() {
+			if f.Key == tagsKey {
+				continue
+			}
+			if fields == nil {
+				fields = map[string]string{}
+			}
+			fields[f.Key] = f.Value
+		}
+
+		entries = append(entries, Entry{
+			Workspace: workspace,
+			Page:      r.Page,
+			Timestamp: r.Date,
+			Summary:   r.Summary(),
+			Body:      r.Body(),
+			Tags:      tags,
+			Fields:    fields,
+			File:      file,
+			Commit:    commits[r.Path][key],
+			ordinal:   key.ordinal,
+		})
+	}
+
+	return entries, nil
+}
+
+// entryKey identifies a log entry in a log file by the time of day it was
+// written on and by its ordinal among the log entries written on the same
+// minute. The key stays the same when the log entry is edited, retagged or
+// formatted with another time format.
+type entryKey struct {
+	time    string
+	ordinal int
+}
+
+func newEntryKey(l Log, logs []Log) entryKey {
+	return entryKey{time: l.Date.Format("15:04"), ordinal: entryOrdinal(l, logs)}
+}
+
+// entryCommits finds the commits which added the log entries of the log file
+// in path from the content of the log file in each commit, as commit messages
+// do not reveal the log entries in encrypted workspaces and are out-of-date
+// after editing.
+func entryCommits(path string) map[entryKey]string {
+	var (
+		logs   []Log
+		hashes []string
+	)
+
+	// Log files outside of git history have no commits
+	commits, _ := git.FileCommits(path)
+
+	// Commits are walked from the oldest and each log entry keeps the commit
+	// of the same log entry in the previous content of the log file
+	for i := len(commits) - 1; i >= 0; i-- {
+		var next []Log
+
+		if b, err := git.FileAt(path, commits[i].Hash); err == nil {
+			if b, err = decrypt(b); err == nil {
+				next, _ = ParseLogs(bytes.NewReader(b))
+			}
+		}
+
+		hashes = matchEntries(logs, hashes, next)
+		for j := range hashes {
+			if len(hashes[j]) == 0 {
+				hashes[j] = commits[i].Hash
+			}
+		}
+		logs = next
+	}
+
+	added := map[entryKey]string{}
+	for i, l := range logs {
+		added[newEntryKey(l, logs)] = hashes[i]
+	}
+
+	return added
+}
+
+// matchEntries returns the commits of the next log entries from the commits
+// of the same log entries in prev. Log entries written on the same minute are
+// the same when their content is equal, and the rest of them in order, so
+// edited log entries are matched too. Log entries not in prev have no commit.
+func matchEntries(prev []Log, commits []string, next []Log) []string {
+	matched := make([]string, len(next))
+	used := make([]bool, len(prev))
+
+	match := func(same func(a Log, b Log) bool) {
+		for i, l := range next {
+			if len(matched[i]) > 0 {
+				continue
+			}
+
+			for j, p := range prev {
+				if !used[j] && l.Date.Truncate(time.Minute).Equal(p.Date.Truncate(time.Minute)) && same(l, p) {
+					matched[i], used[j] = commits[j], true
+					break
+				}
+			}
+		}
+	}
+
+	match(func(a Log, b Log) bool { return strings.Join(a.Data, "\n") == strings.Join(b.Data, "\n") })
+	match(func(Log, Log) bool { return true })
+
+	return matched
+}
+
+// entryOrdinal returns the order of the log entry among the log entries
+// written on the same minute in the log file. The order is counted from the
+// whole log file, so it does not change with the query.
+func entryOrdinal(l Log, logs []Log) int {
+	var n int
+
+	for _, ll := range logs {
+		if !ll.Date.Truncate(time.Minute).Equal(l.Date.Truncate(time.Minute)) {
+			continue
+		}
+
+		if formatLog(ll) == formatLog(l) {
+			break
+		}
+
+		n++
+	}
+
+	return n
+}
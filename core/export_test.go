@@ -0,0 +1,168 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+func TestExportEntries(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	l := NewLog(Meta{Date: testDate, Page: "work"}, "Exported entry\n\nWith body\n", []string{"tag0", "tag1"})
+
+	dir := testWorkspace(t, l)
+	defer os.RemoveAll(dir)
+
+	if err := git.Init(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := git.CommitFiles(dir, []string{"work/16-05-2022.log.md"}, formatLog(l)); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := git.HeadCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := search(dir, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := exportEntries("test", dir, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Entry{{
+		Workspace: "test",
+		Page:      "work",
+		Timestamp: testDate,
+		Summary:   "Exported entry",
+		Body:      "With body",
+		Tags:      []string{"tag0", "tag1"},
+		File:      "work/16-05-2022.log.md",
+		Commit:    hash,
+	}}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected entries %+v did not equal to actual entries %+v", expected, actual)
+	}
+}
+
+func TestEntryOrdinal(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	logs := []Log{
+		NewLog(Meta{Date: testDate.Add(-time.Hour)}, "Earlier entry", nil),
+		NewLog(Meta{Date: testDate}, "First entry", nil),
+		NewLog(Meta{Date: testDate}, "Second entry", []string{"tag0"}),
+	}
+
+	tests := []struct {
+		log      Log
+		expected int
+	}{
+		{log: logs[0], expected: 0},
+		{log: logs[1], expected: 0},
+		{log: logs[2], expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if actual := entryOrdinal(tt.log, logs); tt.expected != actual {
+				t.Fatalf("expected ordinal %d did not match actual ordinal %d", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEntryCommits(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	if err := git.Init(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "16-05-2022.log.md")
+
+	wrote := NewLog(Meta{Date: testDate}, "Wrote the parser", nil)
+	edited := NewLog(Meta{Date: testDate}, "Wrote the parser\n\nWith tests", []string{"go"})
+	reviewed := NewLog(Meta{Date: testDate}, "Reviewed the parser", nil)
+	planned := NewLog(Meta{Date: testDate}, "Planned the release", nil)
+	released := NewLog(Meta{Date: testDate.Add(time.Hour)}, "Released", nil)
+
+	tests := []struct {
+		logs       []Log
+		timeFormat string
+		// expected are the indexes of the commits which added the log entries
+		expected map[entryKey]int
+	}{
+		{
+			logs:     []Log{wrote},
+			expected: map[entryKey]int{{"19:20", 0}: 0},
+		},
+		{
+			// Editing, retagging and another time format keep the commit
+			// which added the log entry
+			logs:       []Log{edited, reviewed},
+			timeFormat: "3:04PM",
+			expected:   map[entryKey]int{{"19:20", 0}: 0, {"19:20", 1}: 1},
+		},
+		{
+			logs:     []Log{reviewed},
+			expected: map[entryKey]int{{"19:20", 0}: 1},
+		},
+		{
+			logs:     []Log{reviewed, planned, released},
+			expected: map[entryKey]int{{"19:20", 0}: 1, {"19:20", 1}: 3, {"20:20", 0}: 3},
+		},
+	}
+
+	var hashes []string
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			config.Config.TimeFormat = tt.timeFormat
+
+			if err := os.WriteFile(path, []byte(formatLogFile(tt.logs)), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := git.CommitFiles(dir, []string{"16-05-2022.log.md"}, "log file"); err != nil {
+				t.Fatal(err)
+			}
+
+			hash, err := git.HeadCommit(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hashes = append(hashes, hash)
+
+			expected := map[entryKey]string{}
+			for k, i := range tt.expected {
+				expected[k] = hashes[i]
+			}
+
+			if actual := entryCommits(path); !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("expected commits %v did not equal to actual commits %v", expected, actual)
+			}
+		})
+	}
+}
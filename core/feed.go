@@ -0,0 +1,215 @@
+package core
+
+import (
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported feed formats
+const (
+	FormatAtom = "atom"
+	FormatRSS  = "rss"
+)
+
+// feedNamespace is the UUID namespace for stable feed and entry ids
+var feedNamespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Author  atomPerson  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Content    atomContent    `xml:"content"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate"`
+	GUID        rssGUID  `xml:"guid"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// feed holds the feed entries from the newest to the oldest
+type feed struct {
+	id      string
+	title   string
+	link    string
+	updated time.Time
+	entries []feedEntry
+}
+
+type feedEntry struct {
+	id string
+	Entry
+}
+
+func newFeed(workspace string, root string, pages []string, entries []Entry) feed {
+	title := workspace
+	if len(pages) > 0 {
+		title = fmt.Sprintf("%s: %s", workspace, strings.Join(pages, ", "))
+	}
+
+	f := feed{
+		id:    uuid(title),
+		title: title,
+		link:  (&url.URL{Scheme: "file", Path: root}).String(),
+	}
+
+	for _, e := range entries {
+		// Log entries written on the same minute are told apart by their order
+		// in the log file
+		id := uuid(fmt.Sprintf("%s/%s/%s/%d", workspace, e.Page, e.Timestamp.Format("2006-01-02T15:04"), e.ordinal))
+
+		f.entries = append([]feedEntry{{id: id, Entry: e}}, f.entries...)
+
+		if e.Timestamp.After(f.updated) {
+			f.updated = e.Timestamp
+		}
+	}
+
+	// Feed without entries is updated when it is generated
+	if f.updated.IsZero() {
+		f.updated = time.Now()
+	}
+
+	return f
+}
+
+func (f feed) atom() atomFeed {
+	a := atomFeed{
+		ID:      f.id,
+		Title:   f.title,
+		Updated: f.updated.Format(time.RFC3339),
+		Link:    &atomLink{Href: f.link},
+		Author:  atomPerson{Name: f.title},
+	}
+
+	for _, e := range f.entries {
+		entry := atomEntry{
+			ID:      e.id,
+			Title:   e.Summary,
+			Updated: e.Timestamp.Format(time.RFC3339),
+			Content: atomContent{Type: "text", Body: e.Body},
+		}
+
+		for _, t := range e.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: t})
+		}
+
+		a.Entries = append(a.Entries, entry)
+	}
+
+	return a
+}
+
+func (f feed) rss() rssFeed {
+	r := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.title,
+			Link:          f.link,
+			Description:   fmt.Sprintf("Log entries from %s", f.title),
+			LastBuildDate: f.updated.Format(time.RFC1123Z),
+		},
+	}
+
+	for _, e := range f.entries {
+		r.Channel.Items = append(r.Channel.Items, rssItem{
+			Title:       e.Summary,
+			Description: e.Body,
+			PubDate:     e.Timestamp.Format(time.RFC1123Z),
+			GUID:        rssGUID{ID: e.id},
+			Categories:  e.Tags,
+		})
+	}
+
+	return r
+}
+
+func writeFeed(out io.Writer, f feed, format string) error {
+	var v interface{} = f.atom()
+	if format == FormatRSS {
+		v = f.rss()
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(out, "\n")
+
+	return err
+}
+
+// uuid returns a name based version 5 UUID URN, which is the same for the
+// same name every time.
+func uuid(name string) string {
+	h := sha1.New()
+	h.Write(feedNamespace[:])
+	h.Write([]byte(name))
+
+	u := h.Sum(nil)[:16]
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFeed(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.UTC)
+
+	entries := []Entry{
+		{Workspace: "test", Timestamp: testDate, Summary: "First", Tags: []string{"tag0"}},
+		{Workspace: "test", Timestamp: testDate, Summary: "Second on the same minute", ordinal: 1},
+		{Workspace: "test", Timestamp: testDate.Add(time.Hour), Summary: "Third", Page: "work"},
+	}
+
+	f := newFeed("test", "/capbook", nil, entries)
+
+	if !f.updated.Equal(testDate.Add(time.Hour)) {
+		t.Fatalf("expected feed to be updated at %s, got %s", testDate.Add(time.Hour), f.updated)
+	}
+
+	if f.entries[0].Summary != "Third" {
+		t.Fatalf("expected newest entry first, got %s", f.entries[0].Summary)
+	}
+
+	ids := map[string]bool{}
+	for _, e := range f.entries {
+		ids[e.id] = true
+	}
+
+	if len(ids) != len(entries) {
+		t.Fatalf("expected unique entry ids, got %v", ids)
+	}
+
+	// Regenerating the feed with a new entry keeps the existing ids
+	regenerated := newFeed("test", "/capbook", nil, append(entries, Entry{Workspace: "test", Timestamp: testDate.Add(2 * time.Hour)}))
+
+	for _, e := range regenerated.entries[1:] {
+		if !ids[e.id] {
+			t.Fatalf("expected entry id %s to be stable between feeds", e.id)
+		}
+	}
+}
+
+func TestNewEmptyFeed(t *testing.T) {
+	f := newFeed("test", "/capbook", nil, nil)
+
+	if f.updated.Year() < 2022 {
+		t.Fatalf("expected empty feed to be updated when generated, got %s", f.updated)
+	}
+}
+
+func TestWriteFeed(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.UTC)
+
+	f := newFeed("test", "/capbook", []string{"work"}, []Entry{
+		{Workspace: "test", Page: "work", Timestamp: testDate, Summary: "Entry <title>", Body: "Body & content", Tags: []string{"tag0", "tag1"}},
+	})
+
+	tests := []struct {
+		format   string
+		expected []string
+	}{
+		{
+			format: FormatAtom,
+			expected: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom">`,
+				`<title>test: work</title>`,
+				`<updated>2022-05-16T19:20:00Z</updated>`,
+				`<title>Entry &lt;title&gt;</title>`,
+				`<content type="text">Body &amp; content</content>`,
+				`<category term="tag1"></category>`,
+			},
+		},
+		{
+			format: FormatRSS,
+			expected: []string{
+				`<rss version="2.0">`,
+				`<pubDate>Mon, 16 May 2022 19:20:00 +0000</pubDate>`,
+				`<guid isPermaLink="false">urn:uuid:`,
+				`<category>tag0</category>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeFeed(&out, f, tt.format); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := xml.Unmarshal(out.Bytes(), new(interface{})); err != nil {
+				t.Fatalf("expected valid xml, got %v", err)
+			}
+
+			for _, e := range tt.expected {
+				if !strings.Contains(out.String(), e) {
+					t.Fatalf("expected feed to contain %s:\n%s", e, out.String())
+				}
+			}
+		})
+	}
+}
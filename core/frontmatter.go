@@ -0,0 +1,283 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+// The front matter of a log file is written in a subset of YAML, which is
+// read by tools like Obsidian and static site generators. Values are either
+// scalars or lists of scalars:
+//
+//	---
+//	date: Monday, May 16, 2022
+//	page: work
+//	workspace: default
+//	authors:
+//	  - Jane Doe
+//	tags:
+//	  - meeting
+//	---
+const frontMatterDelimiter = "---"
+
+// Front matter keys written by caplog, other keys are kept as they are
+const (
+	dateKey      = "date"
+	pageKey      = "page"
+	workspaceKey = "workspace"
+	authorsKey   = "authors"
+	tagsKey      = "tags"
+)
+
+// fileMeta returns the meta of the log file with the log entries. Authors of
+// all log entries are combined, as a log entry written to an existing log file
+// only has the author who wrote it. The workspace and the other front matter
+// lines are read from the log file, which a new log entry does not have.
+func fileMeta(logs []Log) Meta {
+	meta := logs[0].Meta
+	meta.Authors = nil
+
+	for _, l := range logs {
+		meta.Authors = appendUnique(meta.Authors, l.Authors...)
+
+		if len(meta.Workspace) == 0 {
+			meta.Workspace = l.Workspace
+		}
+
+		if meta.extra == nil {
+			meta.extra = l.extra
+		}
+	}
+
+	return meta
+}
+
+// formatFrontMatter formats the front matter of the log file with the tags of
+// all log entries in it. The workspace of a new log file is the current
+// workspace, which is where the log file is written.
+func formatFrontMatter(meta Meta, tags []string) string {
+	var b strings.Builder
+
+	b.WriteString(frontMatterDelimiter + "\n")
+
+	writeYAMLValue(&b, dateKey, formatDate(meta.Date))
+	if len(meta.Page) > 0 {
+		writeYAMLValue(&b, pageKey, meta.Page)
+	}
+	workspace := meta.Workspace
+	if len(workspace) == 0 {
+		workspace = config.Config.CurrentWorkspace
+	}
+	if len(workspace) > 0 {
+		writeYAMLValue(&b, workspaceKey, workspace)
+	}
+	writeYAMLList(&b, authorsKey, meta.Authors)
+	writeYAMLList(&b, tagsKey, tags)
+
+	for _, line := range meta.extra {
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString(frontMatterDelimiter + "\n")
+
+	return b.String()
+}
+
+func writeYAMLValue(b *strings.Builder, key string, value string) {
+	fmt.Fprintf(b, "%s: %s\n", key, yamlScalar(value))
+}
+
+func writeYAMLList(b *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "%s:\n", key)
+	for _, v := range values {
+		fmt.Fprintf(b, "  - %s\n", yamlScalar(v))
+	}
+}
+
+// parseFrontMatter returns the meta defined in the front matter and the
+// index of the first line after it. Tags are not read, because they are
+// always collected from the log entries. Comments and the keys unknown to
+// caplog are kept in the meta, so they are written back as they are.
+func parseFrontMatter(lines []string) (Meta, int, error) {
+	var (
+		meta Meta
+		// key is the latest key, which owns the indented lines below it
+		key string
+	)
+
+	if len(lines) == 0 || lines[0] != frontMatterDelimiter {
+		return meta, 0, ErrMissingFrontMatter
+	}
+
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+
+		if line == frontMatterDelimiter {
+			if meta.Date.IsZero() {
+				return meta, 0, ErrMissingDate
+			}
+			return meta, i + 1, nil
+		}
+
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			meta.extra = append(meta.extra, line)
+			continue
+		}
+
+		// Indented lines and list items belong to the latest key
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-") {
+			switch key {
+			case authorsKey:
+				if item, ok := yamlListItem(line); ok {
+					meta.Authors = append(meta.Authors, item)
+				}
+			case dateKey, pageKey, workspaceKey, tagsKey:
+			default:
+				meta.extra = append(meta.extra, line)
+			}
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(k)
+
+		switch key {
+		case dateKey:
+			date, err := parseDate(parseYAMLScalar(v))
+			if err != nil {
+				return meta, 0, err
+			}
+			meta.Date = date
+		case pageKey:
+			meta.Page = parseYAMLScalar(v)
+		case workspaceKey:
+			meta.Workspace = parseYAMLScalar(v)
+		case authorsKey:
+			meta.Authors = parseYAMLFlowList(v)
+		case tagsKey:
+		default:
+			meta.extra = append(meta.extra, line)
+		}
+	}
+
+	return meta, 0, ErrMissingFrontMatter
+}
+
+// yamlListItem returns the value of a block list item like "  - value".
+func yamlListItem(line string) (string, bool) {
+	item := strings.TrimSpace(line)
+	if item != "-" && !strings.HasPrefix(item, "- ") {
+		return "", false
+	}
+
+	return parseYAMLScalar(strings.TrimPrefix(item, "-")), true
+}
+
+// parseYAMLFlowList parses a list written on a single line like "[a, b]". A
+// single scalar is a list of one value and an empty value is an empty list,
+// which is followed by a block list.
+func parseYAMLFlowList(v string) []string {
+	v = strings.TrimSpace(v)
+	if len(v) == 0 {
+		return nil
+	}
+
+	if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, "]") {
+		return []string{parseYAMLScalar(v)}
+	}
+
+	var values []string
+	for _, item := range strings.Split(strings.Trim(v, "[]"), ",") {
+		if item = parseYAMLScalar(item); len(item) > 0 {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
+
+// parseYAMLScalar returns the value of a plain, single-quoted or
+// double-quoted scalar.
+func parseYAMLScalar(v string) string {
+	v = strings.TrimSpace(v)
+
+	switch {
+	case len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"':
+		if s, err := strconv.Unquote(v); err == nil {
+			return s
+		}
+	case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+	}
+
+	// Comments start with a space and a number sign in plain scalars
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+
+	return v
+}
+
+// yamlScalar returns the value as a plain scalar, or as a double-quoted
+// scalar when the plain scalar would be read as another value or type.
+func yamlScalar(v string) string {
+	if needsQuotes(v) {
+		return strconv.Quote(v)
+	}
+
+	return v
+}
+
+func needsQuotes(v string) bool {
+	if len(v) == 0 || v != strings.TrimSpace(v) {
+		return true
+	}
+
+	if strings.ContainsAny(v[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+
+	if strings.Contains(v, ": ") || strings.Contains(v, " #") || strings.HasSuffix(v, ":") {
+		return true
+	}
+
+	for _, r := range v {
+		if r < ' ' || r == 0x7f {
+			return true
+		}
+	}
+
+	switch strings.ToLower(v) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+
+	_, err := strconv.ParseFloat(v, 64)
+
+	return err == nil
+}
+
+// appendUnique appends the values missing from the slice in order.
+func appendUnique(s []string, values ...string) []string {
+	for _, v := range values {
+		if !contains(s, v) {
+			s = append(s, v)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		content  string
+		expected Meta
+	}{
+		{
+			// Front matter written by earlier versions
+			content:  "---\ndate: Monday, May 16, 2022\n\npage: test\n---\n",
+			expected: Meta{Date: testDate, Page: "test"},
+		},
+		{
+			content:  "---\ndate: 2022-05-16\npage: \"test: page\"\nworkspace: 'it''s'\nauthors:\n  - Jane Doe\n  - \"John\"\ntags:\n  - tag0\n---\n",
+			expected: Meta{Date: testDate, Page: "test: page", Workspace: "it's", Authors: []string{"Jane Doe", "John"}},
+		},
+		{
+			content:  "---\ndate: Monday, May 16, 2022 # comment\nauthors: [Jane Doe, John]\n---\n",
+			expected: Meta{Date: testDate, Authors: []string{"Jane Doe", "John"}},
+		},
+		{
+			content:  "---\ndate: Monday, May 16, 2022\naliases:\n  - standup\ncssclass: wide\n---\n",
+			expected: Meta{Date: testDate, extra: []string{"aliases:", "  - standup", "cssclass: wide"}},
+		},
+		{
+			content:  "---\n# Daily notes\ndate: Monday, May 16, 2022\ncssclass: wide # full width\n# tags: [draft]\n---\n",
+			expected: Meta{Date: testDate, extra: []string{"# Daily notes", "cssclass: wide # full width", "# tags: [draft]"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			lines := strings.Split(strings.TrimSuffix(tt.content, "\n"), "\n")
+
+			actual, n, err := parseFrontMatter(lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if n != len(lines) {
+				t.Fatalf("expected front matter to end on line %d, got %d", len(lines), n)
+			}
+
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("expected meta %#v did not equal to actual meta %#v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatFrontMatter(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	tests := []struct {
+		meta     Meta
+		tags     []string
+		expected string
+	}{
+		{
+			meta:     Meta{Date: testDate},
+			expected: "---\ndate: Monday, May 16, 2022\n---\n",
+		},
+		{
+			meta:     Meta{Date: testDate, Page: "test", Authors: []string{"Jane Doe"}, extra: []string{"cssclass: wide"}},
+			tags:     []string{"tag0", "2022", "#tag1"},
+			expected: "---\ndate: Monday, May 16, 2022\npage: test\nauthors:\n  - Jane Doe\ntags:\n  - tag0\n  - \"2022\"\n  - \"#tag1\"\ncssclass: wide\n---\n",
+		},
+		{
+			meta:     Meta{Date: testDate, Workspace: "other", extra: []string{"# Daily notes"}},
+			expected: "---\ndate: Monday, May 16, 2022\nworkspace: other\n# Daily notes\n---\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual := formatFrontMatter(tt.meta, tt.tags)
+			if tt.expected != actual {
+				t.Fatalf("expected front matter:\n%s\ndid not match actual front matter:\n%s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestYAMLScalar(t *testing.T) {
+	tests := []string{"tag", "Jane Doe", "", " padded", "key: value", "- item", "#tag", "true", "1.5", "it's", "quote \"inside\"", "tab\there"}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if actual := parseYAMLScalar(yamlScalar(tt)); tt != actual {
+				t.Fatalf("expected scalar %q did not match actual scalar %q", tt, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+var ErrNoLogsToImport = errors.New("no log entries to import")
+
+// ImportLogs writes the log entries to their log files in the current
+// workspace and commits all changed log files in a single commit. Log entries
+// are merged in chronological order with the log entries already in the
+// log files.
+func ImportLogs(out io.Writer, logs []Log, source string) error {
+	if len(logs) == 0 {
+		return ErrNoLogsToImport
+	}
+
+	root := config.WorkspacePath()
+
+	// Existing tags are read once for all imported log entries
+	tags := newTagNormalizer()
+
+	normalized := make([]Log, len(logs))
+	for i, l := range logs {
+		l, err := tags.normalize(l)
+		if err != nil {
+			return err
+		}
+		normalized[i] = l
+	}
+	logs = normalized
+
+	if err := initRepository(root); err != nil {
+		return err
+	}
+
+	files := map[string][]Log{}
+	var paths []string
+
+	for _, l := range logs {
+		path := logPath(l)
+		if _, ok := files[path]; !ok {
+			paths = append(paths, path)
+		}
+		files[path] = append(files[path], l)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		existing, err := ReadLogFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		// Authors are collected from all log entries of the log file, so
+		// the author of the import is added to one of them
+		files[path][0].Meta = withAuthor(files[path][0].Meta)
+
+		for _, l := range files[path] {
+			existing = insertLog(existing, l)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+
+		if err := writeFile(path, []byte(formatLogFile(existing))); err != nil {
+			return err
+		}
+	}
+
+	signatures, err := signLogs(logs, nil)
+	if err != nil {
+		return err
+	}
+
+	msg := commitMessage(importCommitPrefix, fmt.Sprintf("%d log entries from %s", len(logs), source))
+
+	if err := commit(msg, time.Time{}, append(paths, signatures...)...); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "imported %d log entries to %d files", len(logs), len(paths))
+
+	return nil
+}
+
+// insertLog inserts the log entry after the log entries written on or
+// before the same minute keeping the log entries in chronological order.
+func insertLog(logs []Log, l Log) []Log {
+	i := sort.Search(len(logs), func(i int) bool {
+		return logs[i].Date.Truncate(time.Minute).After(l.Date.Truncate(time.Minute))
+	})
+
+	logs = append(logs, Log{})
+	copy(logs[i+1:], logs[i:])
+	logs[i] = l
+
+	return logs
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInsertLog(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	first := NewLog(Meta{Date: testDate}, "First", nil)
+	second := NewLog(Meta{Date: testDate.Add(time.Hour)}, "Second", nil)
+
+	tests := []struct {
+		logs     []Log
+		log      Log
+		expected []string
+	}{
+		{
+			log:      first,
+			expected: []string{"First"},
+		},
+		{
+			logs:     []Log{first, second},
+			log:      NewLog(Meta{Date: testDate.Add(30 * time.Minute)}, "Between", nil),
+			expected: []string{"First", "Between", "Second"},
+		},
+		{
+			logs:     []Log{first, second},
+			log:      NewLog(Meta{Date: testDate.Add(-time.Minute)}, "Before", nil),
+			expected: []string{"Before", "First", "Second"},
+		},
+		{
+			logs:     []Log{first, second},
+			log:      NewLog(Meta{Date: testDate.Add(30 * time.Second)}, "Same minute", nil),
+			expected: []string{"First", "Same minute", "Second"},
+		},
+		{
+			logs:     []Log{first, second},
+			log:      NewLog(Meta{Date: testDate.Add(2 * time.Hour)}, "Last", nil),
+			expected: []string{"First", "Second", "Last"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			logs := insertLog(append([]Log{}, tt.logs...), tt.log)
+
+			var actual []string
+			for _, l := range logs {
+				actual = append(actual, l.Summary())
+			}
+
+			if len(tt.expected) != len(actual) {
+				t.Fatalf("expected log order %v did not match actual order %v", tt.expected, actual)
+			}
+
+			for i := range actual {
+				if tt.expected[i] != actual[i] {
+					t.Fatalf("expected log order %v did not match actual order %v", tt.expected, actual)
+				}
+			}
+		})
+	}
+}
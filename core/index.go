@@ -0,0 +1,374 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+const (
+	indexFilename   = "caplog-index.json"
+	indexDateFormat = "2006-01-02"
+)
+
+var (
+	// errIndexOutOfDate is returned when the log files do not match the
+	// index, the query is then answered by reading every log file
+	errIndexOutOfDate = errors.New("index is out of date")
+	ErrIndexUpdateF   = func(e error) error {
+		return fmt.Errorf("changes were committed, but updating the search index failed - %w", e)
+	}
+)
+
+// postings maps log files to the positions of the log entries in the file
+type postings map[string][]int
+
+func (p postings) add(path string, i int) {
+	if n := len(p[path]); n > 0 && p[path][n-1] == i {
+		return
+	}
+
+	p[path] = append(p[path], i)
+}
+
+// index is an inverted index of the log entries in a workspace, which is
+// stored in the git directory of the workspace and kept up-to-date with the
+// HEAD commit.
+type index struct {
+	// Root is the workspace of the index, as a local workspace shares the git
+	// directory with the repository of its project
+	Root string `json:"root"`
+	Head string `json:"head"`
+	// Layout is the template of the log file paths in the index
+	Layout string `json:"layout"`
+	// Files maps log files to the number of log entries in them
+	Files map[string]int      `json:"files"`
+	Words map[string]postings `json:"words"`
+	Tags  map[string]postings `json:"tags"`
+	Pages map[string]postings `json:"pages"`
+	Dates map[string]postings `json:"dates"`
+
+	root string
+	path string
+}
+
+func newIndex(root string, path string) *index {
+	return &index{
+		Root:  root,
+		Files: map[string]int{},
+		Words: map[string]postings{},
+		Tags:  map[string]postings{},
+		Pages: map[string]postings{},
+		Dates: map[string]postings{},
+		root:  root,
+		path:  path,
+	}
+}
+
+// loadIndex reads the index of the workspace in root and updates it with the
+// changes made after the indexed commit.
+func loadIndex(root string) (*index, error) {
+	gitDir, err := git.Dir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := git.HeadCommit(root)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := newIndex(root, filepath.Join(gitDir, indexFilename))
+
+	// Log entries of encrypted workspaces are not stored in plaintext in the
+	// index, an index created before enabling encryption is removed
+	if encrypted() {
+		os.Remove(idx.path)
+		return nil, ErrIndexDisabled
+	}
+
+	if b, err := os.ReadFile(idx.path); err == nil {
+		// Corrupted index and index of another workspace are rebuilt from
+		// scratch
+		if err := json.Unmarshal(b, idx); err != nil || idx.Root != root {
+			idx = newIndex(root, idx.path)
+		}
+	}
+
+	if idx.Head == head && idx.Layout == config.WorkspaceLayout().Template {
+		return idx, nil
+	}
+
+	if err := idx.update(head); err != nil {
+		return nil, err
+	}
+
+	return idx, idx.save()
+}
+
+// update indexes the log files changed between the indexed commit and head.
+// The whole index is rebuilt when the indexed commit is no longer part of
+// the history or the layout of the log files has changed.
+func (idx *index) update(head string) error {
+	var (
+		files []string
+		err   error
+	)
+
+	layout := config.WorkspaceLayout()
+
+	if len(idx.Head) > 0 && idx.Layout == layout.Template {
+		files, err = git.ChangedFiles(idx.root, idx.Head, head)
+	}
+
+	if len(idx.Head) == 0 || idx.Layout != layout.Template || err != nil {
+		*idx = *newIndex(idx.root, idx.path)
+
+		if files, err = git.TrackedFiles(idx.root); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		if _, _, ok := layout.Match(f); !ok {
+			continue
+		}
+
+		idx.remove(f)
+
+		logs, err := ReadLogFile(filepath.Join(idx.root, f))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		idx.add(f, logs)
+	}
+
+	idx.Head = head
+	idx.Layout = layout.Template
+
+	return nil
+}
+
+// updateIndex brings the index of the workspace in root up-to-date with the
+// latest commit. The index is removed when it cannot be updated, so the next
+// search rebuilds it instead of using the index of an older commit.
+func updateIndex(root string) error {
+	// Workspace in a sub-directory of the repository is removed together with
+	// its last log file, there is nothing to index until it is written again
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+
+	_, err := loadIndex(root)
+	if err == nil || errors.Is(err, ErrIndexDisabled) {
+		return nil
+	}
+
+	if gitDir, e := git.Dir(root); e == nil {
+		os.Remove(filepath.Join(gitDir, indexFilename))
+	}
+
+	return ErrIndexUpdateF(err)
+}
+
+func (idx *index) add(file string, logs []Log) {
+	idx.Files[file] = len(logs)
+
+	for i, l := range logs {
+		for _, w := range words(strings.Join(l.Data, "\n")) {
+			addPosting(idx.Words, w, file, i)
+		}
+
+		for _, t := range l.Tags() {
+			addPosting(idx.Tags, t, file, i)
+		}
+
+		addPosting(idx.Pages, l.Page, file, i)
+		addPosting(idx.Dates, l.Date.Format(indexDateFormat), file, i)
+	}
+}
+
+func (idx *index) remove(file string) {
+	delete(idx.Files, file)
+
+	for _, m := range []map[string]postings{idx.Words, idx.Tags, idx.Pages, idx.Dates} {
+		for k, p := range m {
+			delete(p, file)
+			if len(p) == 0 {
+				delete(m, k)
+			}
+		}
+	}
+}
+
+func (idx *index) save() error {
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(idx.path, b, 0644)
+}
+
+// candidates returns the log files and entry positions which may match the
+// query. Entries still need to be matched against the query as keywords are
+// looked up by words and regular expressions are not indexed at all.
+func (idx *index) candidates(q Query) postings {
+	all := postings{}
+	for f, n := range idx.Files {
+		for i := 0; i < n; i++ {
+			all.add(f, i)
+		}
+	}
+
+	sets := []postings{all}
+
+	if len(q.Pages) > 0 {
+		p := postings{}
+		for _, page := range q.Pages {
+			p = union(p, idx.Pages[page])
+		}
+		sets = append(sets, p)
+	}
+
+	for _, t := range q.Tags {
+		sets = append(sets, idx.Tags[t])
+	}
+
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		p := postings{}
+		for d, pp := range idx.Dates {
+			if date, err := time.ParseInLocation(indexDateFormat, d, time.Local); err == nil && q.matchDate(date) {
+				p = union(p, pp)
+			}
+		}
+		sets = append(sets, p)
+	}
+
+	if !q.Regexp {
+		for _, term := range q.Terms {
+			for _, tw := range words(term) {
+				p := postings{}
+				for w, pp := range idx.Words {
+					if strings.Contains(w, tw) {
+						p = union(p, pp)
+					}
+				}
+				sets = append(sets, p)
+			}
+		}
+	}
+
+	result := sets[0]
+	for _, s := range sets[1:] {
+		result = intersect(result, s)
+	}
+
+	return result
+}
+
+// searchIndex answers the query by reading only the log files which have
+// candidate log entries in the index. The log files need to be the same as in
+// the indexed commit, errIndexOutOfDate is returned when a log file has a
+// different number of log entries than in the index.
+func searchIndex(idx *index, q Query) ([]Result, error) {
+	matchers, err := q.matchers()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := idx.candidates(q)
+
+	files := make([]string, 0, len(candidates))
+	for f := range candidates {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	var results []Result
+
+	for _, f := range files {
+		path := filepath.Join(idx.root, f)
+
+		logs, err := ReadLogFile(path)
+		if os.IsNotExist(err) || (err == nil && len(logs) != idx.Files[f]) {
+			return nil, errIndexOutOfDate
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range candidates[f] {
+			if q.match(logs[i], matchers) {
+				results = append(results, Result{Log: logs[i], Path: path})
+			}
+		}
+	}
+
+	return q.sort(results), nil
+}
+
+func addPosting(m map[string]postings, k string, file string, i int) {
+	if _, ok := m[k]; !ok {
+		m[k] = postings{}
+	}
+
+	m[k].add(file, i)
+}
+
+func union(a postings, b postings) postings {
+	for f, is := range b {
+		for _, i := range is {
+			if !containsInt(a[f], i) {
+				a[f] = append(a[f], i)
+			}
+		}
+		sort.Ints(a[f])
+	}
+
+	return a
+}
+
+func intersect(a postings, b postings) postings {
+	result := postings{}
+
+	for f, is := range a {
+		for _, i := range is {
+			if containsInt(b[f], i) {
+				result.add(f, i)
+			}
+		}
+	}
+
+	return result
+}
+
+func containsInt(s []int, v int) bool {
+	for _, vv := range s {
+		if vv == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// words splits the text to lowercase words by any non-alphanumeric character.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
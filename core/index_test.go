@@ -0,0 +1,187 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/git"
+)
+
+func commitWorkspace(t *testing.T, dir string) {
+	if err := git.Init(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	err := walkLogFiles(dir, func(path string) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return git.CommitFiles(dir, []string{rel}, filepath.Base(path))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"go", "caplog"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed pull requests\n\nParser needs tests", []string{"review"}),
+		NewLog(Meta{Date: testDate, Page: "work"}, "Meeting notes", []string{"meeting"}),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	queries := []Query{
+		{},
+		{Terms: []string{"parse"}},
+		{Terms: []string{"needs tests"}},
+		{Terms: []string{"^Wrote"}, Regexp: true},
+		{Tags: []string{"caplog"}},
+		{Pages: []string{"work"}},
+		{Since: testDate.AddDate(0, 0, 1)},
+	}
+
+	assertResults := func(t *testing.T) {
+		idx, err := loadIndex(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, q := range queries {
+			expected, err := search(dir, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := searchIndex(idx, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("expected results %v did not equal to indexed results %v with query %+v", expected, actual, q)
+			}
+		}
+	}
+
+	t.Run("build", assertResults)
+
+	l := NewLog(Meta{Date: testDate.AddDate(0, 0, 1)}, "Planned search", []string{"caplog"})
+	path := fmt.Sprintf("%s/%s", dir, logFilename(l))
+	if err := os.WriteFile(path, []byte(formatLogFile([]Log{l})), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := git.CommitFiles(dir, []string{logFilename(l)}, formatLog(l)); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("update", assertResults)
+}
+
+func TestSearchUncommittedChanges(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	logs := []Log{
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"go"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed pull requests", []string{"review"}),
+	}
+
+	dir := testWorkspace(t, logs...)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	idx, err := loadIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Log entry inserted by hand before the indexed log entries
+	edited := append([]Log{NewLog(Meta{Date: testDate.Add(-time.Hour)}, "Planned the parser", nil)}, logs...)
+	if err := os.WriteFile(filepath.Join(dir, logFilename(logs[0])), []byte(formatLogFile(edited)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := searchIndex(idx, Query{}); !errors.Is(err, errIndexOutOfDate) {
+		t.Fatalf("expected error %v, got %v", errIndexOutOfDate, err)
+	}
+
+	assertResults := func(t *testing.T) {
+		for _, q := range []Query{{}, {Terms: []string{"parser"}}, {Terms: []string{"lexer"}}, {Tags: []string{"review"}}} {
+			expected, err := search(dir, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := Search(q)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("expected results %v did not equal to actual results %v with query %+v", expected, actual, q)
+			}
+		}
+	}
+
+	t.Run("inserted", assertResults)
+
+	// Log entry edited by hand keeping the number of log entries
+	edited = append([]Log{NewLog(Meta{Date: testDate}, "Wrote the lexer", []string{"go"})}, logs[1:]...)
+	if err := os.WriteFile(filepath.Join(dir, logFilename(logs[0])), []byte(formatLogFile(edited)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("edited", assertResults)
+}
+
+func TestSearchSharedRepository(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	// Local workspaces of two projects in the same repository
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate, Page: "first"}, "Wrote the parser", nil),
+		NewLog(Meta{Date: testDate, Page: "second"}, "Reviewed the parser", nil),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	tests := []struct {
+		workspace string
+		term      string
+		expected  string
+	}{
+		{workspace: "first", term: "wrote", expected: "Wrote the parser"},
+		{workspace: "second", term: "reviewed", expected: "Reviewed the parser"},
+		{workspace: "first", term: "wrote", expected: "Wrote the parser"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.workspace, func(t *testing.T) {
+			defer testConfig(t, filepath.Join(dir, tt.workspace), "")()
+
+			results, err := Search(Query{Terms: []string{tt.term}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != 1 || results[0].Summary() != tt.expected {
+				t.Fatalf("expected %q, got %v", tt.expected, results)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+var (
+	ErrEmptyInput     = errors.New("piped input is empty")
+	ErrInputTooLargeF = func(limit int64) error {
+		return fmt.Errorf("piped input is larger than the %d byte limit, raise the limit with max_input_size or pipe less output", limit)
+	}
+)
+
+var backticks = regexp.MustCompile("`{3,}")
+
+// ReadInput reads a log entry from piped input. Input is written below the
+// summary in a fenced code block, so hashtags in command output are not read
+// as tags. When no summary is given, the first line of the input is the
+// summary. Input larger than the configured limit is not read, so huge
+// outputs are not committed by accident.
+func ReadInput(r io.Reader, summary string) (string, error) {
+	limit := config.MaxInputSize()
+
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return "", err
+	}
+
+	if int64(len(b)) > limit {
+		return "", ErrInputTooLargeF(limit)
+	}
+
+	input := strings.Trim(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	if len(strings.TrimSpace(input)) == 0 {
+		return "", ErrEmptyInput
+	}
+
+	if len(summary) == 0 {
+		summary, input, _ = strings.Cut(input, "\n")
+		if input = strings.TrimLeft(input, "\n"); len(input) == 0 {
+			return summary, nil
+		}
+	}
+
+	fence := codeFence(input)
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s", summary, fence, input, fence), nil
+}
+
+// codeFence returns a code fence longer than any backtick fence in the
+// content, so the content cannot close the code block.
+func codeFence(content string) string {
+	n := 3
+	for _, m := range backticks.FindAllString(content, -1) {
+		if len(m) >= n {
+			n = len(m) + 1
+		}
+	}
+
+	return strings.Repeat("`", n)
+}
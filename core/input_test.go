@@ -0,0 +1,44 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+func TestReadInput(t *testing.T) {
+	prev := config.Config
+	defer func() { config.Config = prev }()
+
+	config.Config.MaxInputSize = "64"
+
+	tests := []struct {
+		input      string
+		summary    string
+		expected   string
+		expectsErr bool
+	}{
+		{input: "Wrote the parser\n", expected: "Wrote the parser"},
+		{input: "\nWrote the parser\r\nParser needs tests\n\n", expected: "Wrote the parser\n```\nParser needs tests\n```"},
+		{input: "Build failed\n#include <stdio.h>\n", expected: "Build failed\n```\n#include <stdio.h>\n```"},
+		{input: "ok  \tcaplog/core\n", summary: "Ran tests", expected: "Ran tests\n```\nok  \tcaplog/core\n```"},
+		{input: "```go\nfunc main() {}\n```\n", summary: "Snippet", expected: "Snippet\n````\n```go\nfunc main() {}\n```\n````"},
+		{input: strings.Repeat("a", 64), expected: strings.Repeat("a", 64)},
+		{input: strings.Repeat("a", 65), expectsErr: true},
+		{input: " \n\n", summary: "Ran tests", expectsErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := ReadInput(strings.NewReader(tt.input), tt.summary)
+			if tt.expectsErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectsErr, err)
+			}
+
+			if tt.expected != actual {
+				t.Fatalf("expected input %q did not equal to actual input %q", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Metadata of a log entry is written as a paragraph of "key: value" lines
+// after the log entry. The paragraph is separated from the log entry with an
+// empty line and its lines are not indented:
+//
+//	16:30	Reviewed pull requests
+//		Parser needs tests
+//
+//	tags: review, parser
+//	mood: focused
+//	duration: 1h30m
+
+var fieldKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+var (
+	ErrInvalidFieldKeyF = func(key string) error {
+		return fmt.Errorf("invalid metadata key \"%s\", keys are letters, digits, - and _", key)
+	}
+	ErrInvalidFieldValueF = func(key string) error { return fmt.Errorf("metadata value of \"%s\" must be a single line", key) }
+)
+
+// Field is a key: value pair in the metadata of a log entry.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// NewField returns the metadata field after checking that it can be written
+// to a log file and read back.
+func NewField(key string, value string) (Field, error) {
+	if !fieldKey.MatchString(key) {
+		return Field{}, ErrInvalidFieldKeyF(key)
+	}
+
+	value = strings.TrimSpace(value)
+	if strings.ContainsAny(value, "\r\n") {
+		return Field{}, ErrInvalidFieldValueF(key)
+	}
+
+	return Field{Key: key, Value: value}, nil
+}
+
+func (f Field) String() string {
+	return fmt.Sprintf("%s: %s", f.Key, f.Value)
+}
+
+// parseField parses a metadata line.
+func parseField(line string) (Field, bool) {
+	k, v, ok := strings.Cut(line, ":")
+	if !ok || !fieldKey.MatchString(k) || (len(v) > 0 && v[0] != ' ') {
+		return Field{}, false
+	}
+
+	return Field{Key: k, Value: strings.TrimSpace(v)}, true
+}
+
+// setField sets the value of the field with the same key or appends the
+// field. Fields with empty values are removed.
+func setField(fields []Field, f Field) []Field {
+	for i := range fields {
+		if fields[i].Key == f.Key {
+			if len(f.Value) == 0 {
+				return append(fields[:i:i], fields[i+1:]...)
+			}
+			fields[i] = f
+			return fields
+		}
+	}
+
+	if len(f.Value) == 0 {
+		return fields
+	}
+
+	return append(fields, f)
+}
+
+// formatFields formats the metadata paragraph as the last value of the log
+// entry data.
+func formatFields(fields []Field) string {
+	lines := make([]string, len(fields))
+	for i, f := range fields {
+		lines[i] = f.String()
+	}
+
+	return "\n" + strings.Join(lines, "\n")
+}
+
+// Fields returns the metadata of the log entry in the order it is written.
+func (l Log) Fields() []Field {
+	i := l.metadataIndex()
+	if i < 0 {
+		return nil
+	}
+
+	var fields []Field
+	for _, line := range strings.Split(strings.TrimLeft(l.Data[i], "\n"), "\n") {
+		f, _ := parseField(line)
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// Field returns the value of the metadata field with the key.
+func (l Log) Field(key string) string {
+	for _, f := range l.Fields() {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+
+	return ""
+}
+
+// metadataIndex returns the index of the metadata paragraph, which is always
+// the last value of the log entry data, or -1 if the log entry has no
+// metadata.
+func (l Log) metadataIndex() int {
+	i := len(l.Data) - 1
+	if i < 0 {
+		return -1
+	}
+
+	v := l.Data[i]
+
+	// The paragraph is separated from the log entry with an empty line,
+	// which the reader joins to the paragraph. A single tags line is read as
+	// metadata as well.
+	if !strings.HasPrefix(v, "\n") {
+		if !strings.HasPrefix(v, tagsKey+": ") || strings.Contains(v, "\n") {
+			return -1
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimLeft(v, "\n"), "\n") {
+		if _, ok := parseField(line); !ok {
+			return -1
+		}
+	}
+
+	return i
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLogFields(t *testing.T) {
+	tests := []struct {
+		log      Log
+		expected []Field
+		body     string
+	}{
+		{
+			log:  NewLog(Meta{}, "Log entry\n\nnote: in the body", nil),
+			body: "note: in the body",
+		},
+		{
+			log:      NewLog(Meta{}, "Log entry", []string{"tag0"}, Field{Key: "mood", Value: "good"}, Field{Key: "duration", Value: "1h"}),
+			expected: []Field{{Key: "tags", Value: "tag0"}, {Key: "mood", Value: "good"}, {Key: "duration", Value: "1h"}},
+		},
+		{
+			log:      NewLog(Meta{}, "Log entry\n\nWith body", nil, Field{Key: "mood", Value: "good"}, Field{Key: "mood", Value: "great"}, Field{Key: "project", Value: ""}),
+			expected: []Field{{Key: "mood", Value: "great"}},
+			body:     "With body",
+		},
+		{
+			log:  Log{Data: []string{"Log entry", "\nnot metadata"}},
+			body: "not metadata",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if actual := tt.log.Fields(); !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("expected fields %v did not equal to actual fields %v", tt.expected, actual)
+			}
+
+			if actual := tt.log.Body(); tt.body != actual {
+				t.Fatalf("expected body %q did not match actual body %q", tt.body, actual)
+			}
+		})
+	}
+}
+
+func TestNewField(t *testing.T) {
+	tests := []struct {
+		key        string
+		value      string
+		expected   Field
+		expectsErr bool
+	}{
+		{key: "mood", value: " good ", expected: Field{Key: "mood", Value: "good"}},
+		{key: "time_spent", value: "1h30m", expected: Field{Key: "time_spent", Value: "1h30m"}},
+		{key: "", value: "value", expectsErr: true},
+		{key: "two words", value: "value", expectsErr: true},
+		{key: "note", value: "two\nlines", expectsErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := NewField(tt.key, tt.value)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if tt.expected != actual {
+				t.Fatalf("expected field %v did not equal to actual field %v", tt.expected, actual)
+			}
+		})
+	}
+}
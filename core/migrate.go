@@ -0,0 +1,156 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/layout"
+)
+
+var (
+	ErrSameLayout     = errors.New("workspace already uses the layout")
+	ErrMigrateTargetF = func(path string) error { return fmt.Errorf("cannot move log file to %s, file already exists", path) }
+	ErrRollbackF      = func(e error, rollback error) error {
+		return fmt.Errorf("%w and moving the log files back failed - %v", e, rollback)
+	}
+)
+
+// move is a log file moved from one path to another
+type move struct {
+	from string
+	to   string
+}
+
+// MigrateLayout moves the log files of the current workspace to the paths
+// of the layout in a single commit. The layout of the workspace is changed in
+// the config with setLayout before the commit. The moved log files are moved
+// back if moving any of the log files or changing the layout fails.
+func MigrateLayout(out io.Writer, template string, setLayout func(template string) error) error {
+	to, err := layout.Parse(template)
+	if err != nil {
+		return err
+	}
+
+	from := config.WorkspaceLayout()
+	if from.Template == to.Template {
+		return ErrSameLayout
+	}
+
+	root := config.WorkspacePath()
+
+	moves, err := layoutMoves(root, from, to)
+	if err != nil {
+		return err
+	}
+
+	if moved, err := moveFiles(root, moves); err != nil {
+		return rollbackMoves(root, moved, err)
+	}
+
+	if err := setLayout(to.Template); err != nil {
+		return rollbackMoves(root, moves, err)
+	}
+
+	if len(moves) > 0 {
+		paths := make([]string, 0, len(moves)*2)
+		for _, m := range moves {
+			paths = append(paths, m.from, m.to)
+		}
+
+		msg := commitMessage(migrateCommitPrefix, fmt.Sprintf("%d log files to %s", len(moves), to.Template))
+
+		if err := commit(msg, time.Time{}, paths...); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "moved %d log files to layout %s", len(moves), to.Template)
+
+	return nil
+}
+
+// layoutMoves returns the moves of the log files in the root from one layout
+// to another. Existing files are never overwritten.
+func layoutMoves(root string, from layout.Layout, to layout.Layout) ([]move, error) {
+	var moves []move
+
+	err := walkLogFiles(root, func(path string) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		page, date, ok := from.Match(filepath.ToSlash(rel))
+		if !ok {
+			return nil
+		}
+
+		target := filepath.Join(root, page, to.Path(date))
+		if target == path {
+			return nil
+		}
+
+		if _, err := os.Stat(target); err == nil {
+			return ErrMigrateTargetF(target)
+		}
+
+		moves = append(moves, move{from: path, to: target})
+
+		return nil
+	})
+
+	return moves, err
+}
+
+// moveFiles moves the files and returns the moves done before any error.
+func moveFiles(root string, moves []move) ([]move, error) {
+	for i, m := range moves {
+		if err := os.MkdirAll(filepath.Dir(m.to), os.ModePerm); err != nil {
+			return moves[:i], err
+		}
+
+		if err := os.Rename(m.from, m.to); err != nil {
+			return moves[:i], err
+		}
+
+		removeEmptyDirs(root, filepath.Dir(m.from))
+	}
+
+	return moves, nil
+}
+
+// rollbackMoves moves the files back in reverse order and returns the error
+// that caused the rollback.
+func rollbackMoves(root string, moves []move, cause error) error {
+	back := make([]move, 0, len(moves))
+	for i := len(moves) - 1; i >= 0; i-- {
+		back = append(back, move{from: moves[i].to, to: moves[i].from})
+	}
+
+	if _, err := moveFiles(root, back); err != nil {
+		return ErrRollbackF(cause, err)
+	}
+
+	return cause
+}
+
+// removeEmptyDirs removes the directory and its parents up to the root while
+// they are empty.
+func removeEmptyDirs(root string, dir string) {
+	for dir != root && len(dir) > len(root) {
+		if entries, err := os.ReadDir(dir); err != nil || len(entries) > 0 {
+			return
+		}
+
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+	"github.com/erikjuhani/caplog/layout"
+)
+
+// testSetLayout sets the layout of the test workspace without writing the
+// config file.
+func testSetLayout(template string) error {
+	config.Config.Layouts = map[string]string{"test": template}
+	return nil
+}
+
+func TestMigrateLayout(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	logs := []Log{
+		NewLog(Meta{Date: testDate}, "First entry", []string{"tag0"}),
+		NewLog(Meta{Date: testDate.AddDate(0, 1, 0)}, "Second entry", nil),
+		NewLog(Meta{Date: testDate, Page: "work"}, "Meeting notes", nil),
+	}
+
+	dir := testWorkspace(t, logs...)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	tests := []struct {
+		template   string
+		expected   []string
+		expectsErr bool
+	}{
+		{
+			template:   layout.Default,
+			expected:   []string{"16-05-2022.log.md", "16-06-2022.log.md", "work/16-05-2022.log.md"},
+			expectsErr: true,
+		},
+		{
+			template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md",
+			expected: []string{"2022/05/2022-05-16.md", "2022/06/2022-06-16.md", "work/2022/05/2022-05-16.md"},
+		},
+		{
+			template: layout.Default,
+			expected: []string{"16-05-2022.log.md", "16-06-2022.log.md", "work/16-05-2022.log.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			err := MigrateLayout(io.Discard, tt.template, testSetLayout)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if config.WorkspaceLayout().Template != tt.template {
+				t.Fatalf("expected layout %q, got %q", tt.template, config.WorkspaceLayout().Template)
+			}
+
+			tracked, err := git.TrackedFiles(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tracked) != len(tt.expected) {
+				t.Fatalf("expected tracked files %v, got %v", tt.expected, tracked)
+			}
+
+			for i, path := range tt.expected {
+				if tracked[i] != path {
+					t.Fatalf("expected tracked files %v, got %v", tt.expected, tracked)
+				}
+
+				if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			results, err := Search(Query{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != len(logs) {
+				t.Fatalf("expected %d log entries, got %d", len(logs), len(results))
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "2022")); !os.IsNotExist(err) && tt.template == layout.Default {
+				t.Fatalf("expected empty layout directories to be removed, got %v", err)
+			}
+		})
+	}
+
+	if err := UndoLog(io.Discard); err != ErrUndoMigration {
+		t.Fatalf("expected %v, got %v", ErrUndoMigration, err)
+	}
+}
+
+func TestMigrateLayoutRollback(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	expected := []string{"16-05-2022.log.md", "16-05-2023.log.md"}
+
+	tests := []struct {
+		// blocked is a file blocking the move of the second log file
+		blocked   string
+		setLayout func(string) error
+	}{
+		{blocked: "2023", setLayout: testSetLayout},
+		{setLayout: func(string) error { return errors.New("config is not writable") }},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			dir := testWorkspace(t,
+				NewLog(Meta{Date: testDate}, "First entry", nil),
+				NewLog(Meta{Date: testDate.AddDate(1, 0, 0)}, "Second entry", nil),
+			)
+			defer os.RemoveAll(dir)
+
+			commitWorkspace(t, dir)
+
+			defer testConfig(t, dir, "")()
+
+			if len(tt.blocked) > 0 {
+				if err := os.WriteFile(filepath.Join(dir, tt.blocked), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := MigrateLayout(io.Discard, "{{year}}/{{yyyy-mm-dd}}.md", tt.setLayout); err == nil {
+				t.Fatal("expected migration to fail")
+			}
+
+			if config.WorkspaceLayout().Template != layout.Default {
+				t.Fatalf("expected default layout, got %q", config.WorkspaceLayout().Template)
+			}
+
+			for _, path := range expected {
+				if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+					t.Fatalf("expected log file to be moved back - %v", err)
+				}
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "2022")); !os.IsNotExist(err) {
+				t.Fatalf("expected layout directory to be removed, got %v", err)
+			}
+		})
+	}
+}
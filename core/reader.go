@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingFrontMatter = errors.New("missing front matter")
+	ErrMissingDate        = errors.New("front matter is missing a date")
+	ErrInvalidLineF       = func(n int, line string) error { return fmt.Errorf("invalid log entry on line %d: %q", n, line) }
+)
+
+// ReadLogFile reads the log file in the given path and returns the log
+// entries it contains in the order they were written.
+func ReadLogFile(path string) ([]Log, error) {
+	b, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseLogs(bytes.NewReader(b))
+}
+
+// ParseLogs parses log entries from the capbook format written by WriteLog.
+// Formatting the returned logs again results in the exact same content.
+func ParseLogs(r io.Reader) ([]Log, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+
+	meta, n, err := parseFrontMatter(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		logs []Log
+		// Empty lines are either separators between entries or part of
+		// a multiline value, which is only known when the next line is read
+		blanks int
+	)
+
+	for i := n; i < len(lines); i++ {
+		line := lines[i]
+
+		if len(line) == 0 {
+			blanks++
+			continue
+		}
+
+		if ts, summary, ok := parseEntryHeader(line); ok {
+			l := Log{Meta: meta, Data: []string{summary}}
+			l.Date = time.Date(meta.Date.Year(), meta.Date.Month(), meta.Date.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.Local)
+			logs = append(logs, l)
+			blanks = 0
+			continue
+		}
+
+		if len(logs) == 0 {
+			return nil, ErrInvalidLineF(i+1, line)
+		}
+
+		l := &logs[len(logs)-1]
+
+		if strings.HasPrefix(line, "\t") {
+			l.Data[len(l.Data)-1] += strings.Repeat("\n", blanks)
+			l.Data = append(l.Data, line[1:])
+		} else {
+			// Lines without indentation continue the previous value,
+			// for example metadata is written as "\ntags: <tag>"
+			l.Data[len(l.Data)-1] += strings.Repeat("\n", blanks+1) + line
+		}
+
+		blanks = 0
+	}
+
+	return logs, nil
+}
+
+func parseEntryHeader(line string) (time.Time, string, bool) {
+	ts, summary, ok := strings.Cut(line, "\t")
+	if !ok {
+		return time.Time{}, "", false
+	}
+
+	t, ok := parseClock(ts)
+	if !ok {
+		return time.Time{}, "", false
+	}
+
+	return t, summary, true
+}
+
+// Summary returns the first line of the log entry.
+func (l Log) Summary() string {
+	if len(l.Data) == 0 {
+		return ""
+	}
+
+	return l.Data[0]
+}
+
+// Body returns the log entry content after the summary line without the
+// metadata and surrounding empty lines.
+func (l Log) Body() string {
+	if len(l.Data) < 2 {
+		return ""
+	}
+
+	body := l.Data[1:]
+	if i := l.metadataIndex(); i > 0 {
+		body = l.Data[1:i]
+	}
+
+	return strings.Trim(strings.Join(body, "\n"), "\n")
+}
+
+// Tags returns the tags written in the metadata of the log entry.
+func (l Log) Tags() []string {
+	var tags []string
+	for _, t := range strings.Split(l.Field(tagsKey), ",") {
+		if t = strings.TrimSpace(t); len(t) > 0 {
+			tags = append(tags, t)
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogs(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	tests := []struct {
+		logs       []Log
+		expectsErr bool
+	}{
+		{
+			logs: []Log{NewLog(Meta{Date: testDate}, "New log entry", nil)},
+		},
+		{
+			logs: []Log{
+				NewLog(Meta{Date: testDate}, "New log entry\n\nWith body\n\n\tIndented line", []string{"tag0", "tag1"}),
+				NewLog(Meta{Date: testDate.Add(time.Hour)}, "Second entry", []string{"tag2"}),
+				NewLog(Meta{Date: testDate.Add(2 * time.Hour)}, "Third entry\nwith content\n", nil),
+			},
+		},
+		{
+			logs: []Log{
+				NewLog(Meta{Date: testDate, Page: "test"}, "Log entry in a page", nil),
+				NewLog(Meta{Date: testDate, Page: "test"}, "", []string{"tag0"}),
+			},
+		},
+		{
+			logs: []Log{
+				NewLog(Meta{Date: testDate, Authors: []string{"Jane Doe"}}, "Entry with metadata\n\nWith body", []string{"tag0"}, Field{Key: "mood", Value: "good"}),
+				NewLog(Meta{Date: testDate.Add(time.Hour), Authors: []string{"Jane Doe"}}, "Entry with a field", nil, Field{Key: "project", Value: "caplog"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			content := formatLogFile(tt.logs)
+
+			actual, err := ParseLogs(strings.NewReader(content))
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if !reflect.DeepEqual(tt.logs, actual) {
+				t.Fatalf("expected logs %v did not equal to actual logs %v", tt.logs, actual)
+			}
+
+			if formatted := formatLogFile(actual); content != formatted {
+				t.Fatalf("expected log file:\n%s\ndid not match formatted log file:\n%s", content, formatted)
+			}
+		})
+	}
+}
+
+func TestParseLogsErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"22:34\tNo front matter\n",
+		"---\npage: test\n---\n",
+		"---\ndate: Monday, May 16, 2022\n---\n\n\tBody without entry\n",
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if _, err := ParseLogs(strings.NewReader(tt)); err == nil {
+				t.Fatalf("expected error when parsing %q", tt)
+			}
+		})
+	}
+}
+
+func TestLogTags(t *testing.T) {
+	tests := []struct {
+		log      Log
+		expected []string
+	}{
+		{
+			log: Log{},
+		},
+		{
+			log:      NewLog(Meta{}, "Log entry", []string{"tag0", "tag1"}),
+			expected: []string{"tag0", "tag1"},
+		},
+		{
+			log:      Log{Data: []string{"Log entry", "", "tags: tag0,tag1 "}},
+			expected: []string{"tag0", "tag1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual := tt.log.Tags()
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("expected tags %v did not equal to actual tags %v", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package core
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+// Query describes which log entries are returned from a search.
+// Zero values match every log entry.
+type Query struct {
+	// Terms that all need to match the log entry content,
+	// matched case-insensitively or as regular expressions
+	Terms  []string
+	Regexp bool
+	// Tags that all need to be present in the log entry
+	Tags []string
+	// Pages of which at least one needs to match the log entry page
+	Pages []string
+	// Since and Until limit log entries to the given days, both inclusive
+	Since time.Time
+	Until time.Time
+	// Limit restricts the results to the given number of the most recent
+	// log entries
+	Limit int
+}
+
+type Result struct {
+	Log
+	Path string
+}
+
+type matcher func(string) bool
+
+// Search returns log entries matching the query from the current workspace
+// ordered by date.
+func Search(q Query) ([]Result, error) {
+	root := config.WorkspacePath()
+
+	// Index has the log entries of the latest commit, so workspaces without
+	// any commits or with uncommitted changes are searched by reading every
+	// log file
+	if !committed(root) {
+		return search(root, q)
+	}
+
+	idx, err := loadIndex(root)
+	if err != nil {
+		return search(root, q)
+	}
+
+	results, err := searchIndex(idx, q)
+	if errors.Is(err, errIndexOutOfDate) {
+		return search(root, q)
+	}
+
+	return results, err
+}
+
+// committed reports whether all log files in the workspace in root are the
+// same as in the HEAD commit.
+func committed(root string) bool {
+	files, err := git.UncommittedFiles(root)
+	if err != nil {
+		return false
+	}
+
+	layout := config.WorkspaceLayout()
+	for _, f := range files {
+		if _, _, ok := layout.Match(f); ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Tags returns the tags used in the log entries of the current workspace in
+// alphabetical order.
+func Tags() ([]string, error) {
+	results, err := Search(Query{})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var tags []string
+
+	for _, r := range results {
+		for _, t := range r.Tags() {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+
+	sort.Strings(tags)
+
+	return tags, nil
+}
+
+func search(root string, q Query) ([]Result, error) {
+	matchers, err := q.matchers()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+
+	err = walkLogFiles(root, func(path string) error {
+		if !q.matchFilename(root, path) {
+			return nil
+		}
+
+		logs, err := ReadLogFile(path)
+		if err != nil {
+			return err
+		}
+
+		for _, l := range logs {
+			if q.match(l, matchers) {
+				results = append(results, Result{Log: l, Path: path})
+			}
+		}
+
+		return nil
+	})
+
+	return q.sort(results), err
+}
+
+// walkLogFiles calls fn for each log file in the layout of the workspace
+// found under root skipping hidden directories like .git.
+func walkLogFiles(root string, fn func(path string) error) error {
+	// Workspace without any logs yet
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if _, ok := logFileDate(root, path); !ok {
+			return nil
+		}
+
+		return fn(path)
+	})
+}
+
+// sort orders the results by date and drops the results exceeding the limit.
+func (q Query) sort(results []Result) []Result {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Date.Before(results[j].Date)
+	})
+
+	if q.Limit > 0 && len(results) > q.Limit {
+		return results[len(results)-q.Limit:]
+	}
+
+	return results
+}
+
+func (q Query) matchers() ([]matcher, error) {
+	var matchers []matcher
+
+	for _, term := range q.Terms {
+		if q.Regexp {
+			re, err := regexp.Compile(term)
+			if err != nil {
+				return nil, err
+			}
+			matchers = append(matchers, re.MatchString)
+			continue
+		}
+
+		term := strings.ToLower(term)
+		matchers = append(matchers, func(s string) bool {
+			return strings.Contains(strings.ToLower(s), term)
+		})
+	}
+
+	return matchers, nil
+}
+
+// matchFilename skips files which are named after a day outside the query
+// date range without reading them.
+func (q Query) matchFilename(root string, path string) bool {
+	date, ok := logFileDate(root, path)
+	if !ok {
+		return true
+	}
+
+	return q.matchDate(date)
+}
+
+// logFileDate returns the day of the log file from its path in the layout of
+// the workspace. Files which are not log files of the layout do not match.
+func logFileDate(root string, path string) (time.Time, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	_, date, ok := config.WorkspaceLayout().Match(filepath.ToSlash(rel))
+
+	return date, ok
+}
+
+func (q Query) matchDate(date time.Time) bool {
+	d := day(date)
+
+	if !q.Since.IsZero() && d.Before(day(q.Since)) {
+		return false
+	}
+
+	if !q.Until.IsZero() && d.After(day(q.Until)) {
+		return false
+	}
+
+	return true
+}
+
+func (q Query) match(l Log, matchers []matcher) bool {
+	if !q.matchDate(l.Date) {
+		return false
+	}
+
+	if len(q.Pages) > 0 && !contains(q.Pages, l.Page) {
+		return false
+	}
+
+	tags := l.Tags()
+	for _, t := range q.Tags {
+		if !contains(tags, t) {
+			return false
+		}
+	}
+
+	// Tags and other metadata have their own filters, terms match only the
+	// written log entry
+	content := l.Summary()
+	if body := l.Body(); len(body) > 0 {
+		content += "\n" + body
+	}
+
+	for _, m := range matchers {
+		if !m(content) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func contains(s []string, v string) bool {
+	for _, vv := range s {
+		if vv == v {
+			return true
+		}
+	}
+
+	return false
+}
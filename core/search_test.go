@@ -0,0 +1,139 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testWorkspace(t *testing.T, logs ...Log) string {
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]Log{}
+	for _, l := range logs {
+		loc := dir
+		if len(l.Page) > 0 {
+			loc = fmt.Sprintf("%s/%s", dir, l.Page)
+		}
+
+		if err := os.MkdirAll(loc, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+
+		path := fmt.Sprintf("%s/%s", loc, logFilename(l))
+		files[path] = append(files[path], l)
+	}
+
+	for path, logs := range files {
+		if err := os.WriteFile(path, []byte(formatLogFile(logs)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestSearch(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"go", "caplog"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed pull requests\n\nParser needs tests", []string{"review"}),
+		NewLog(Meta{Date: testDate.AddDate(0, 0, 1)}, "Planned search", []string{"caplog"}),
+		NewLog(Meta{Date: testDate, Page: "work"}, "Meeting notes", []string{"meeting"}),
+	)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		{
+			query:    Query{},
+			expected: []string{"Wrote the parser", "Meeting notes", "Reviewed pull requests", "Planned search"},
+		},
+		{
+			query:    Query{Terms: []string{"PARSER"}},
+			expected: []string{"Wrote the parser", "Reviewed pull requests"},
+		},
+		{
+			query:    Query{Terms: []string{"caplog"}},
+			expected: nil,
+		},
+		{
+			query:    Query{Terms: []string{"tags"}},
+			expected: nil,
+		},
+		{
+			query:    Query{Terms: []string{"tests"}},
+			expected: []string{"Reviewed pull requests"},
+		},
+		{
+			query:    Query{Terms: []string{"^Planned"}, Regexp: true},
+			expected: []string{"Planned search"},
+		},
+		{
+			query:    Query{Tags: []string{"caplog"}},
+			expected: []string{"Wrote the parser", "Planned search"},
+		},
+		{
+			query:    Query{Pages: []string{"work"}},
+			expected: []string{"Meeting notes"},
+		},
+		{
+			query:    Query{Since: testDate.AddDate(0, 0, 1)},
+			expected: []string{"Planned search"},
+		},
+		{
+			query:    Query{Until: testDate, Tags: []string{"caplog"}},
+			expected: []string{"Wrote the parser"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			results, err := search(dir, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var actual []string
+			for _, r := range results {
+				actual = append(actual, r.Data[0])
+			}
+
+			if fmt.Sprint(tt.expected) != fmt.Sprint(actual) {
+				t.Fatalf("expected results %v did not match actual results %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTags(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"go", "caplog"}),
+		NewLog(Meta{Date: testDate.AddDate(0, 0, 1)}, "Planned search", []string{"caplog"}),
+		NewLog(Meta{Date: testDate, Page: "work"}, "Meeting notes", []string{"meeting"}),
+		NewLog(Meta{Date: testDate, Page: "work"}, "No tags", nil),
+	)
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	actual, err := Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"caplog", "go", "meeting"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected tags %v did not match actual %v", expected, actual)
+	}
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+// ANSI escape codes used in colorized output
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+var ErrInvalidSpanF = func(s string) error {
+	return fmt.Errorf("\"%s\" is not a valid day or span (today, yesterday, week, month or <date>)", s)
+}
+
+// ParseSpan returns the first and the last day of the given span, which is
+// either today, yesterday, week, month or a date. Week and month are the
+// current calendar week and month starting from Monday and the first day
+// of the month.
+func ParseSpan(s string, now time.Time) (time.Time, time.Time, error) {
+	today := day(now)
+
+	switch s {
+	case "", "today":
+		return today, today, nil
+	case "yesterday":
+		yesterday := today.AddDate(0, 0, -1)
+		return yesterday, yesterday, nil
+	case "week":
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		return monday, monday.AddDate(0, 0, 6), nil
+	case "month":
+		first := today.AddDate(0, 0, 1-today.Day())
+		return first, first.AddDate(0, 1, -1), nil
+	}
+
+	date, err := ParseDate(s)
+	if err != nil {
+		return date, date, ErrInvalidSpanF(s)
+	}
+
+	return date, date, nil
+}
+
+// Pages returns the pages in the current workspace including the workspace
+// root as an empty page. Directories of the layout, like years, are not pages.
+func Pages() ([]string, error) {
+	pages := []string{""}
+
+	entries, err := os.ReadDir(config.WorkspacePath())
+	if os.IsNotExist(err) {
+		return pages, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	layout := config.WorkspaceLayout()
+
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && !layout.IsLayoutDir(e.Name()) {
+			pages = append(pages, e.Name())
+		}
+	}
+
+	return pages, nil
+}
+
+// ReadLogs reads the log entries written between the given days from the
+// given pages of the current workspace. Logs are ordered by day and page.
+func ReadLogs(from time.Time, to time.Time, pages []string) ([]Log, error) {
+	var logs []Log
+
+	for d := day(from); !d.After(day(to)); d = d.AddDate(0, 0, 1) {
+		for _, p := range pages {
+			l := Log{Meta: Meta{Date: d, Page: p}}
+
+			ll, err := ReadLogFile(logPath(l))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			logs = append(logs, ll...)
+		}
+	}
+
+	return logs, nil
+}
+
+// ShowLogs writes the log entries under a heading for each day and page.
+// Times, summaries and tags are highlighted when color is true.
+func ShowLogs(out io.Writer, logs []Log, color bool) {
+	paint := func(code string, s string) string {
+		if !color {
+			return s
+		}
+		return code + s + colorReset
+	}
+
+	for i, l := range logs {
+		if i == 0 || !day(logs[i-1].Date).Equal(day(l.Date)) || logs[i-1].Page != l.Page {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+
+			heading := formatDate(l.Date)
+			if len(l.Page) > 0 {
+				heading = fmt.Sprintf("%s (%s)", heading, l.Page)
+			}
+
+			fmt.Fprintf(out, "%s\n\n", paint(colorBold, heading))
+		} else {
+			fmt.Fprintln(out)
+		}
+
+		if len(l.Data) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(out, "%s\t%s\n", paint(colorYellow, l.Date.Format(config.TimeFormat())), paint(colorBold, l.Data[0]))
+
+		metadata := l.metadataIndex()
+
+		for i, v := range l.Data[1:] {
+			for _, line := range strings.Split(v, "\n") {
+				if len(line) == 0 {
+					fmt.Fprintln(out)
+					continue
+				}
+
+				if i+1 == metadata {
+					line = paint(colorCyan, line)
+				}
+				fmt.Fprintf(out, "\t%s\n", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestParseSpan(t *testing.T) {
+	// Wednesday
+	now := time.Date(2022, 5, 18, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		span       string
+		from       time.Time
+		to         time.Time
+		expectsErr bool
+	}{
+		{
+			span: "today",
+			from: time.Date(2022, 5, 18, 0, 0, 0, 0, time.Local),
+			to:   time.Date(2022, 5, 18, 0, 0, 0, 0, time.Local),
+		},
+		{
+			span: "yesterday",
+			from: time.Date(2022, 5, 17, 0, 0, 0, 0, time.Local),
+			to:   time.Date(2022, 5, 17, 0, 0, 0, 0, time.Local),
+		},
+		{
+			span: "week",
+			from: time.Date(2022, 5, 16, 0, 0, 0, 0, time.Local),
+			to:   time.Date(2022, 5, 22, 0, 0, 0, 0, time.Local),
+		},
+		{
+			span: "month",
+			from: time.Date(2022, 5, 1, 0, 0, 0, 0, time.Local),
+			to:   time.Date(2022, 5, 31, 0, 0, 0, 0, time.Local),
+		},
+		{
+			span: "2022-05-14",
+			from: time.Date(2022, 5, 14, 0, 0, 0, 0, time.Local),
+			to:   time.Date(2022, 5, 14, 0, 0, 0, 0, time.Local),
+		},
+		{
+			span:       "fortnight",
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.span, func(t *testing.T) {
+			from, to, err := ParseSpan(tt.span, now)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr {
+				return
+			}
+
+			if !tt.from.Equal(from) || !tt.to.Equal(to) {
+				t.Fatalf("expected span %s - %s did not match actual span %s - %s", tt.from, tt.to, from, to)
+			}
+		})
+	}
+}
+
+func TestShowLogs(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	logs := []Log{
+		NewLog(Meta{Date: testDate}, "First entry\nwith body", []string{"tag0"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Second entry", nil),
+		NewLog(Meta{Date: testDate, Page: "work"}, "Entry in page", nil),
+	}
+
+	expected := `Monday, May 16, 2022
+
+19:20	First entry
+	with body
+
+	tags: tag0
+
+20:20	Second entry
+
+Monday, May 16, 2022 (work)
+
+19:20	Entry in page
+`
+
+	var out bytes.Buffer
+	ShowLogs(&out, logs, false)
+
+	if expected != out.String() {
+		t.Fatalf("expected output:\n%s\ndid not match actual output:\n%s", expected, out.String())
+	}
+}
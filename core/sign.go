@@ -0,0 +1,368 @@
+package core
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/crypt"
+)
+
+// Signatures of the log entries are stored in the workspace and committed
+// together with the signed log entries. The manifest is the signed list of
+// the keys of all signed log entries, so a log entry removed together with
+// its signature is still reported.
+const (
+	signaturesFilename = ".caplog/signatures"
+	manifestFilename   = ".caplog/manifest"
+)
+
+var (
+	ErrNoSigningKey   = errors.New("signing_key is not set in config")
+	ErrVerifyFailedF  = func(n int) error { return fmt.Errorf("%d log entries failed verification", n) }
+	ErrInvalidSigLine = func(n int) error { return fmt.Errorf("invalid signature on line %d", n) }
+	ErrManifestF      = func(status string) error { return fmt.Errorf("signature manifest is %s", status) }
+)
+
+// signatures maps log entry keys to the signatures of the log entries
+// written on the same minute
+type signatures map[string][][]byte
+
+// logKey identifies the log entries written on the same minute in a page.
+func logKey(l Log) string {
+	key := l.Date.Format("2006-01-02 15:04")
+	if len(l.Page) > 0 {
+		key = fmt.Sprintf("%s %s", l.Page, key)
+	}
+
+	return key
+}
+
+// signedContent returns the signed content of the log entry, which covers
+// the page and the time of the log entry in addition to the log entry. The
+// time is always in the default format, so changing the time format does not
+// invalidate the signatures.
+func signedContent(l Log) []byte {
+	return []byte(logKey(l) + "\n" + formatLogAt(l, config.DefaultTimeFormat))
+}
+
+func signaturesPath(root string) string {
+	return filepath.Join(root, signaturesFilename)
+}
+
+func manifestPath(root string) string {
+	return filepath.Join(root, manifestFilename)
+}
+
+// readSignatures reads the signatures, which are encrypted in encrypted
+// workspaces like the log files, as they reveal the pages and the times of
+// the log entries.
+func readSignatures(root string) (signatures, error) {
+	sigs := signatures{}
+
+	b, err := readFile(signaturesPath(root))
+	if os.IsNotExist(err) {
+		return sigs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, line := range strings.Split(strings.TrimSuffix(string(b), "\n"), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		encoded, key, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, ErrInvalidSigLine(i + 1)
+		}
+
+		sig, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidSigLine(i + 1)
+		}
+
+		sigs[key] = append(sigs[key], sig)
+	}
+
+	return sigs, nil
+}
+
+func (s signatures) keys() []string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// manifest returns the keys of the signed log entries, one line for each
+// signature.
+func (s signatures) manifest() string {
+	var sb strings.Builder
+	for _, k := range s.keys() {
+		for range s[k] {
+			sb.WriteString(k + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// write writes the signatures sorted by the log entry keys and the manifest
+// signed with the key.
+func (s signatures) write(root string, key crypt.SigningKey) error {
+	var sb strings.Builder
+	for _, k := range s.keys() {
+		for _, sig := range s[k] {
+			fmt.Fprintf(&sb, "%s %s\n", base64.StdEncoding.EncodeToString(sig), k)
+		}
+	}
+
+	path := signaturesPath(root)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := writeFile(path, []byte(sb.String())); err != nil {
+		return err
+	}
+
+	manifest := s.manifest()
+
+	sig, err := key.Sign([]byte(manifest))
+	if err != nil {
+		return err
+	}
+
+	return writeFile(manifestPath(root), []byte(base64.StdEncoding.EncodeToString(sig)+"\n"+manifest))
+}
+
+// readManifest returns the number of signed log entries for each log entry
+// key in the manifest after verifying the manifest signature.
+func readManifest(root string, key crypt.SigningKey) (map[string]int, error) {
+	b, err := readFile(manifestPath(root))
+	if os.IsNotExist(err) {
+		return nil, ErrManifestF("missing")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, manifest, _ := strings.Cut(string(b), "\n")
+
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || !key.Verify([]byte(manifest), sig) {
+		return nil, ErrManifestF("modified")
+	}
+
+	counts := map[string]int{}
+	for _, k := range strings.Split(strings.TrimSuffix(manifest, "\n"), "\n") {
+		if len(k) > 0 {
+			counts[k]++
+		}
+	}
+
+	return counts, nil
+}
+
+// counts returns the number of signatures for each log entry key.
+func (s signatures) counts() map[string]int {
+	counts := map[string]int{}
+	for k, sigs := range s {
+		counts[k] = len(sigs)
+	}
+
+	return counts
+}
+
+func (s signatures) add(key crypt.SigningKey, l Log) error {
+	sig, err := key.Sign(signedContent(l))
+	if err != nil {
+		return err
+	}
+
+	s[logKey(l)] = append(s[logKey(l)], sig)
+
+	return nil
+}
+
+// remove removes the signature of the log entry if it has one.
+func (s signatures) remove(key crypt.SigningKey, l Log) {
+	k := logKey(l)
+
+	for i, sig := range s[k] {
+		if key.Verify(signedContent(l), sig) {
+			s[k] = append(s[k][:i:i], s[k][i+1:]...)
+			break
+		}
+	}
+
+	if len(s[k]) == 0 {
+		delete(s, k)
+	}
+}
+
+// signLogs adds signatures for the signed log entries and removes the
+// signatures of the unsigned log entries when signing is enabled. The
+// signatures file and the manifest are returned to be committed with the log
+// files.
+func signLogs(signed []Log, unsigned []Log) ([]string, error) {
+	path := config.SigningKeyPath()
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	key, err := crypt.ReadSigningKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root := config.WorkspacePath()
+
+	sigs, err := readSignatures(root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range unsigned {
+		sigs.remove(key, l)
+	}
+
+	for _, l := range signed {
+		if err := sigs.add(key, l); err != nil {
+			return nil, err
+		}
+	}
+
+	return []string{signaturesPath(root), manifestPath(root)}, sigs.write(root, key)
+}
+
+// VerifyLogs verifies the signatures of all log entries in the current
+// workspace and reports the log entries which are modified, removed or
+// unsigned after signing.
+func VerifyLogs(out io.Writer) error {
+	path := config.SigningKeyPath()
+	if len(path) == 0 {
+		return ErrNoSigningKey
+	}
+
+	key, err := crypt.ReadSigningKey(path)
+	if err != nil {
+		return err
+	}
+
+	root := config.WorkspacePath()
+
+	sigs, err := readSignatures(root)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+
+	// Without a valid manifest the removed log entries are reported only
+	// when their signatures are left
+	manifest, err := readManifest(root, key)
+	if err != nil && len(sigs) > 0 {
+		fmt.Fprintf(out, "%s\n", err)
+		failed++
+	}
+
+	logs := map[string][]Log{}
+
+	err = walkLogFiles(root, func(path string) error {
+		ll, err := ReadLogFile(path)
+		if err != nil {
+			return err
+		}
+
+		for _, l := range ll {
+			logs[logKey(l)] = append(logs[logKey(l)], l)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(logs)+len(sigs))
+	for k := range logs {
+		keys = append(keys, k)
+	}
+	for _, signed := range []map[string]int{sigs.counts(), manifest} {
+		for k := range signed {
+			if _, ok := logs[k]; !ok && !contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	var verified int
+
+	for _, k := range keys {
+		unused := append([][]byte{}, sigs[k]...)
+
+		// Log entries signed on the minute are either in the manifest or
+		// have a signature left
+		signed := len(unused)
+		if manifest[k] > signed {
+			signed = manifest[k]
+		}
+
+		var unmatched []Log
+
+	next:
+		for _, l := range logs[k] {
+			for i, sig := range unused {
+				if key.Verify(signedContent(l), sig) {
+					unused = append(unused[:i], unused[i+1:]...)
+					verified++
+					continue next
+				}
+			}
+
+			unmatched = append(unmatched, l)
+		}
+
+		// Log entries without a valid signature are modified as long as
+		// there are signed log entries left for the same minute
+		left := signed - (len(logs[k]) - len(unmatched))
+
+		for i, l := range unmatched {
+			status := "unsigned"
+			if i < left {
+				status = "modified"
+			}
+			fmt.Fprintf(out, "%s: %s %s\n", status, k, l.Summary())
+		}
+
+		for i := len(unmatched); i < left; i++ {
+			fmt.Fprintf(out, "removed: %s\n", k)
+		}
+
+		failed += len(unmatched)
+		if left > len(unmatched) {
+			failed += left - len(unmatched)
+		}
+	}
+
+	fmt.Fprintf(out, "%d log entries verified\n", verified)
+
+	if failed > 0 {
+		return ErrVerifyFailedF(failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/crypt"
+)
+
+// testSigningKey generates a signing key in the directory and enables
+// signing with it.
+func testSigningKey(t *testing.T, dir string) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Config.SigningKey = filepath.Join(dir, ".signing.pem")
+	if err := os.WriteFile(config.Config.SigningKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyLogs(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	testSigningKey(t, dir)
+
+	logs := []Log{
+		NewLog(Meta{Date: testDate}, "First entry", nil),
+		NewLog(Meta{Date: testDate}, "Second entry on the same minute", nil),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Third entry", []string{"tag0"}),
+		NewLog(Meta{Date: testDate.Add(2 * time.Hour)}, "Fourth entry", nil),
+	}
+
+	for _, l := range logs {
+		if err := WriteLog(io.Discard, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := RemoveLog(io.Discard, "", testDate.Add(2*time.Hour), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := VerifyLogs(&out); err != nil {
+		t.Fatalf("expected signed log entries to verify, got %v\n%s", err, out.String())
+	}
+
+	// Tamper with the log file outside of caplog
+	path := filepath.Join(dir, "16-05-2022.log.md")
+	tampered := []Log{
+		NewLog(Meta{Date: testDate}, "First entry", nil),
+		NewLog(Meta{Date: testDate}, "Second entry on the same minute, modified", nil),
+		NewLog(Meta{Date: testDate.Add(3 * time.Hour)}, "Unsigned entry", nil),
+	}
+	if err := os.WriteFile(path, []byte(formatLogFile(tampered)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := VerifyLogs(&out); err == nil {
+		t.Fatal("expected tampered log entries to fail verification")
+	}
+
+	expected := []string{
+		"modified: 2022-05-16 19:20 Second entry on the same minute, modified",
+		"removed: 2022-05-16 20:20",
+		"unsigned: 2022-05-16 22:20 Unsigned entry",
+		"1 log entries verified",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(out.String(), e) {
+			t.Fatalf("expected verify output to contain %q, got:\n%s", e, out.String())
+		}
+	}
+}
+
+func TestVerifyLogsAfterTimeFormatChange(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	testSigningKey(t, dir)
+
+	if err := WriteLog(io.Discard, NewLog(Meta{Date: testDate}, "Signed with the default time format", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Config.TimeFormat = "3:04PM"
+
+	var out bytes.Buffer
+	if err := VerifyLogs(&out); err != nil {
+		t.Fatalf("expected log entries to verify after changing the time format, got %v\n%s", err, out.String())
+	}
+
+	// Writing to the log file rewrites the signed log entry with the new format
+	if err := WriteLog(io.Discard, NewLog(Meta{Date: testDate.Add(time.Hour)}, "Signed with a 12-hour time format", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Config.TimeFormat = ""
+
+	out.Reset()
+	if err := VerifyLogs(&out); err != nil {
+		t.Fatalf("expected log entries to verify after changing the time format back, got %v\n%s", err, out.String())
+	}
+
+	if !strings.Contains(out.String(), "2 log entries verified") {
+		t.Fatalf("expected 2 verified log entries, got:\n%s", out.String())
+	}
+}
+
+func TestVerifyRemovedLogs(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	testSigningKey(t, dir)
+
+	logs := []Log{
+		NewLog(Meta{Date: testDate}, "First entry", nil),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Second entry", nil),
+	}
+
+	for _, l := range logs {
+		if err := WriteLog(io.Discard, l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Remove the second log entry together with its signature outside of
+	// caplog
+	if err := os.WriteFile(filepath.Join(dir, "16-05-2022.log.md"), []byte(formatLogFile(logs[:1])), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sigs, err := readSignatures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delete(sigs, logKey(logs[1]))
+
+	var sb strings.Builder
+	for _, k := range sigs.keys() {
+		for _, sig := range sigs[k] {
+			sb.WriteString(base64.StdEncoding.EncodeToString(sig) + " " + k + "\n")
+		}
+	}
+
+	if err := os.WriteFile(signaturesPath(dir), []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := VerifyLogs(&out); err == nil {
+		t.Fatalf("expected removed log entry to fail verification, got:\n%s", out.String())
+	}
+
+	if !strings.Contains(out.String(), "removed: 2022-05-16 20:20") {
+		t.Fatalf("expected verify output to report the removed log entry, got:\n%s", out.String())
+	}
+
+	// Manifest cannot be changed to match without the signing key
+	if err := os.WriteFile(manifestPath(dir), []byte(sigs.manifest()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := VerifyLogs(&out); err == nil {
+		t.Fatalf("expected modified manifest to fail verification, got:\n%s", out.String())
+	}
+
+	if !strings.Contains(out.String(), ErrManifestF("modified").Error()) {
+		t.Fatalf("expected verify output to report the modified manifest, got:\n%s", out.String())
+	}
+}
+
+func TestEncryptedSignatures(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+	config.Config.Encryption = map[string]config.Encryption{"test": {}}
+
+	t.Setenv(config.PassphraseEnv, "passphrase")
+
+	testSigningKey(t, dir)
+
+	if err := WriteLog(io.Discard, NewLog(Meta{Date: testDate, Page: "secret"}, "Secret meeting notes", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{signaturesPath(dir), manifestPath(dir)} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !crypt.IsEncrypted(b) {
+			t.Fatalf("expected %s to be encrypted, got %s", path, b)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := VerifyLogs(&out); err != nil {
+		t.Fatalf("expected encrypted log entries to verify, got %v\n%s", err, out.String())
+	}
+}
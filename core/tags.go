@@ -0,0 +1,378 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+)
+
+var (
+	ErrInvalidTagF = func(tag string) error {
+		return fmt.Errorf("invalid tag \"%s\", tags cannot be empty or contain commas", tag)
+	}
+	ErrTagNotFoundF = func(tags []string) error {
+		return fmt.Errorf("no log entries tagged with %s", strings.Join(tags, ", "))
+	}
+	ErrSameTag        = errors.New("new tag name is the same as the old name")
+	ErrTagNotAllowedF = func(tag string, allowed []string) error {
+		return fmt.Errorf("tag \"%s\" is not allowed in this workspace, allowed tags are: %s", tag, strings.Join(allowed, ", "))
+	}
+)
+
+// TagUsage tells how many log entries have the tag and when the tag was used
+// last.
+type TagUsage struct {
+	Name     string
+	Count    int
+	LastUsed time.Time
+}
+
+// TagUsages returns the usage of each tag in the current workspace in
+// alphabetical order. Tags differing only in case are next to each other.
+func TagUsages() ([]TagUsage, error) {
+	results, err := Search(Query{})
+	if err != nil {
+		return nil, err
+	}
+
+	usages := map[string]*TagUsage{}
+
+	for _, r := range results {
+		for _, t := range r.Tags() {
+			u, ok := usages[t]
+			if !ok {
+				u = &TagUsage{Name: t}
+				usages[t] = u
+			}
+
+			u.Count++
+			if r.Date.After(u.LastUsed) {
+				u.LastUsed = r.Date
+			}
+		}
+	}
+
+	sorted := make([]TagUsage, 0, len(usages))
+	for _, u := range usages {
+		sorted = append(sorted, *u)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := strings.ToLower(sorted[i].Name), strings.ToLower(sorted[j].Name)
+		if a == b {
+			return sorted[i].Name < sorted[j].Name
+		}
+		return a < b
+	})
+
+	return sorted, nil
+}
+
+// RenameTag renames the tag in all log entries of the current workspace. The
+// new name follows the tag policy of the workspace like the tags of written
+// log entries.
+func RenameTag(out io.Writer, from string, to string) error {
+	to, err := normalizeTag(to)
+	if err != nil {
+		return err
+	}
+
+	if from == to {
+		return ErrSameTag
+	}
+
+	return retag(out, []string{from}, to, fmt.Sprintf("rename %s to %s", from, to))
+}
+
+// MergeTags replaces the tags with the tag in all log entries of the current
+// workspace. A log entry with more than one of the tags has the tag only once.
+// The tag follows the tag policy of the workspace like the tags of written log
+// entries.
+func MergeTags(out io.Writer, tags []string, into string) error {
+	into, err := normalizeTag(into)
+	if err != nil {
+		return err
+	}
+
+	return retag(out, tags, into, fmt.Sprintf("merge %s into %s", strings.Join(tags, ", "), into))
+}
+
+// retag replaces the tags with the tag in every log file of the current
+// workspace and commits all changed log files in a single commit.
+func retag(out io.Writer, tags []string, to string, change string) error {
+	for _, t := range tags {
+		if !validTag(t) {
+			return ErrInvalidTagF(t)
+		}
+	}
+
+	root := config.WorkspacePath()
+
+	var (
+		paths            []string
+		files            = map[string][]Log{}
+		retagged, before []Log
+	)
+
+	// All log files are read before any of them is written, so nothing is
+	// changed when a log file cannot be read
+	err := walkLogFiles(root, func(path string) error {
+		logs, err := ReadLogFile(path)
+		if err != nil {
+			return err
+		}
+
+		for i, l := range logs {
+			if t, ok := replaceTags(l.Tags(), tags, to); ok {
+				logs[i] = l.withTags(t)
+				before = append(before, l)
+				retagged = append(retagged, logs[i])
+
+				if _, ok := files[path]; !ok {
+					paths = append(paths, path)
+				}
+				files[path] = logs
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(retagged) == 0 {
+		return ErrTagNotFoundF(tags)
+	}
+
+	for _, path := range paths {
+		if err := writeFile(path, []byte(formatLogFile(files[path]))); err != nil {
+			return err
+		}
+	}
+
+	signatures, err := signLogs(retagged, before)
+	if err != nil {
+		return err
+	}
+
+	msg := commitMessage(tagCommitPrefix, fmt.Sprintf("%s in %d log entries", change, len(retagged)))
+
+	if err := commit(msg, time.Time{}, append(paths, signatures...)...); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "retagged %d log entries in %d files", len(retagged), len(paths))
+
+	return nil
+}
+
+// replaceTags replaces the tags with the tag keeping the order of the tags.
+// The tags are not changed when none of them is replaced.
+func replaceTags(tags []string, from []string, to string) ([]string, bool) {
+	var (
+		replaced []string
+		changed  bool
+	)
+
+	for _, t := range tags {
+		if contains(from, t) {
+			t, changed = to, true
+		}
+		replaced = appendUnique(replaced, t)
+	}
+
+	return replaced, changed
+}
+
+func validTag(tag string) bool {
+	return len(strings.TrimSpace(tag)) > 0 && !strings.ContainsAny(tag, ",\r\n")
+}
+
+// withTags returns the log entry with the tags in its metadata.
+func (l Log) withTags(tags []string) Log {
+	fields := setField(l.Fields(), Field{Key: tagsKey, Value: strings.Join(tags, ", ")})
+
+	data := l.Data
+	if i := l.metadataIndex(); i >= 0 {
+		data = data[:i]
+	}
+
+	l.Data = append([]string{}, data...)
+	if len(fields) > 0 {
+		l.Data = append(l.Data, formatFields(fields))
+	}
+
+	return l
+}
+
+// hashtag matches a #hashtag preceded by whitespace, so headings, URL
+// fragments and issue numbers like #42 are not hashtags
+var hashtag = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+
+// hashtags returns the hashtags written in the text outside of fenced code
+// blocks.
+func hashtags(text string) []string {
+	var (
+		tags []string
+		// fence is the fence of the code block the line is in
+		fence string
+	)
+
+	for _, line := range strings.Split(text, "\n") {
+		if l := strings.TrimSpace(line); strings.HasPrefix(l, "```") {
+			f := l[:len(l)-len(strings.TrimLeft(l, "`"))]
+			switch {
+			case len(fence) == 0:
+				fence = f
+			case len(f) >= len(fence) && f == l:
+				fence = ""
+			}
+			continue
+		}
+
+		if len(fence) > 0 {
+			continue
+		}
+
+		for _, m := range hashtag.FindAllStringSubmatch(line, -1) {
+			t := strings.TrimRight(m[1], "/-")
+			if strings.Trim(t, "0123456789") == "" {
+				continue
+			}
+			tags = appendUnique(tags, t)
+		}
+	}
+
+	return tags
+}
+
+// normalizeTags merges the hashtags of the log entry to its tags and applies
+// the tag policy of the current workspace to them.
+func normalizeTags(l Log) (Log, error) {
+	return newTagNormalizer().normalize(l)
+}
+
+// tagNormalizer applies the tag policy of the current workspace to several
+// log entries. Existing tags are read only once and the normalized tags are
+// added to them, so tags differing in case are unified within the log entries
+// too.
+type tagNormalizer struct {
+	policy   config.TagPolicy
+	existing []TagUsage
+	loaded   bool
+}
+
+func newTagNormalizer() *tagNormalizer {
+	return &tagNormalizer{policy: config.WorkspaceTagPolicy()}
+}
+
+func (n *tagNormalizer) normalize(l Log) (Log, error) {
+	tags := appendUnique(l.Tags(), hashtags(l.Summary()+"\n"+l.Body())...)
+
+	var normalized []string
+	for _, t := range tags {
+		t, err := n.tag(t)
+		if err != nil {
+			return l, err
+		}
+
+		normalized = appendUnique(normalized, t)
+	}
+
+	if n.loaded {
+		n.use(normalized, l.Date)
+	}
+
+	if reflect.DeepEqual(normalized, l.Tags()) {
+		return l, nil
+	}
+
+	return l.withTags(normalized), nil
+}
+
+// tag applies the case policy and the allowed tags of the workspace to the
+// tag.
+func (n *tagNormalizer) tag(t string) (string, error) {
+	switch n.policy.Case {
+	case config.TagCaseLower:
+		t = strings.ToLower(t)
+	case config.TagCaseExisting:
+		if !n.loaded {
+			// Workspace without any log entries has no existing tags
+			n.existing, _ = TagUsages()
+			n.loaded = true
+		}
+		t = existingTag(n.existing, t)
+	}
+
+	if len(n.policy.Allowed) > 0 {
+		allowed, ok := findFold(n.policy.Allowed, t)
+		if !ok {
+			return "", ErrTagNotAllowedF(t, n.policy.Allowed)
+		}
+		t = allowed
+	}
+
+	return t, nil
+}
+
+// normalizeTag validates the tag and applies the tag policy of the current
+// workspace to it.
+func normalizeTag(tag string) (string, error) {
+	if !validTag(tag) {
+		return "", ErrInvalidTagF(tag)
+	}
+
+	return newTagNormalizer().tag(tag)
+}
+
+// use adds the tags used at the date to the existing tags.
+func (n *tagNormalizer) use(tags []string, date time.Time) {
+	for _, t := range tags {
+		i := 0
+		for i < len(n.existing) && n.existing[i].Name != t {
+			i++
+		}
+
+		if i == len(n.existing) {
+			n.existing = append(n.existing, TagUsage{Name: t})
+		}
+
+		n.existing[i].Count++
+		if date.After(n.existing[i].LastUsed) {
+			n.existing[i].LastUsed = date
+		}
+	}
+}
+
+// existingTag returns the most used existing tag differing from the tag only
+// in case, or the tag itself when there is no such tag.
+func existingTag(usages []TagUsage, tag string) string {
+	count := 0
+	for _, u := range usages {
+		if strings.EqualFold(u.Name, tag) && u.Count > count {
+			tag, count = u.Name, u.Count
+		}
+	}
+
+	return tag
+}
+
+// findFold finds the tag from the tags ignoring case.
+func findFold(tags []string, tag string) (string, bool) {
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return strings.TrimSpace(t), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,376 @@
+package core
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+func TestReplaceTags(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		from     []string
+		to       string
+		expected []string
+		changed  bool
+	}{
+		{tags: []string{"go", "parser"}, from: []string{"golang"}, to: "go", expected: []string{"go", "parser"}},
+		{tags: []string{"golang", "parser"}, from: []string{"golang"}, to: "go", expected: []string{"go", "parser"}, changed: true},
+		{tags: []string{"Go", "parser", "golang"}, from: []string{"Go", "golang"}, to: "go", expected: []string{"go", "parser"}, changed: true},
+		{tags: []string{"parser", "golang", "go"}, from: []string{"golang"}, to: "go", expected: []string{"parser", "go"}, changed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, changed := replaceTags(tt.tags, tt.from, tt.to)
+			if changed != tt.changed {
+				t.Fatalf("expected changed %t, got %t", tt.changed, changed)
+			}
+
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("expected tags %v did not equal to actual tags %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRetag(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"golang", "parser"}, Field{Key: "mood", Value: "good"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed pull requests", []string{"Go"}),
+		NewLog(Meta{Date: testDate.AddDate(0, 0, 1), Page: "work"}, "Meeting notes", []string{"go", "meeting"}),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	tests := []struct {
+		run        func() error
+		expected   map[string][]string
+		expectsErr bool
+	}{
+		{
+			run:        func() error { return RenameTag(io.Discard, "missing", "go") },
+			expectsErr: true,
+		},
+		{
+			run:        func() error { return RenameTag(io.Discard, "go", "go") },
+			expectsErr: true,
+		},
+		{
+			run:        func() error { return MergeTags(io.Discard, []string{"golang"}, "a, b") },
+			expectsErr: true,
+		},
+		{
+			run: func() error { return RenameTag(io.Discard, "golang", "go") },
+			expected: map[string][]string{
+				"Wrote the parser":       {"go", "parser"},
+				"Reviewed pull requests": {"Go"},
+				"Meeting notes":          {"go", "meeting"},
+			},
+		},
+		{
+			run: func() error { return MergeTags(io.Discard, []string{"Go", "meeting"}, "go") },
+			expected: map[string][]string{
+				"Wrote the parser":       {"go", "parser"},
+				"Reviewed pull requests": {"go"},
+				"Meeting notes":          {"go"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			head, err := git.HeadCommit(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.run()
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			commits, err := git.Commits(dir, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expectsErr {
+				if commits[0].Hash != head {
+					t.Fatalf("expected nothing to be committed, got %v", commits[0])
+				}
+				return
+			}
+
+			if !strings.HasPrefix(commits[0].Message, tagCommitPrefix) {
+				t.Fatalf("expected tags to be changed in a single commit, got %v", commits[0])
+			}
+
+			results, err := search(dir, Query{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, r := range results {
+				if expected := tt.expected[r.Summary()]; !reflect.DeepEqual(expected, r.Tags()) {
+					t.Fatalf("expected tags %v of %q did not equal to actual tags %v", expected, r.Summary(), r.Tags())
+				}
+
+				if r.Summary() == "Wrote the parser" && r.Field("mood") != "good" {
+					t.Fatalf("expected other metadata to be kept, got %v", r.Fields())
+				}
+			}
+		})
+	}
+}
+
+func TestRetagPolicy(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	tests := []struct {
+		policy     config.TagPolicy
+		run        func() error
+		expected   []string
+		expectsErr bool
+	}{
+		{
+			policy:   config.TagPolicy{Case: config.TagCaseLower},
+			run:      func() error { return RenameTag(io.Discard, "golang", "Go") },
+			expected: []string{"go", "parser"},
+		},
+		{
+			policy:   config.TagPolicy{Case: config.TagCaseExisting},
+			run:      func() error { return MergeTags(io.Discard, []string{"golang"}, "PARSER") },
+			expected: []string{"parser"},
+		},
+		{
+			policy:   config.TagPolicy{Allowed: []string{"Go", "parser"}},
+			run:      func() error { return RenameTag(io.Discard, "golang", "go") },
+			expected: []string{"Go", "parser"},
+		},
+		{
+			policy:     config.TagPolicy{Allowed: []string{"parser"}},
+			run:        func() error { return RenameTag(io.Discard, "golang", "go") },
+			expectsErr: true,
+		},
+		{
+			// New name is the same as the old name after the case policy
+			policy:     config.TagPolicy{Case: config.TagCaseExisting},
+			run:        func() error { return RenameTag(io.Discard, "golang", "GOLANG") },
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			dir := testWorkspace(t, NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"golang", "parser"}))
+			defer os.RemoveAll(dir)
+
+			commitWorkspace(t, dir)
+
+			defer testConfig(t, dir, "")()
+			config.Config.Tags = map[string]config.TagPolicy{"test": tt.policy}
+
+			err := tt.run()
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr {
+				return
+			}
+
+			results, err := search(dir, Query{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != 1 || !reflect.DeepEqual(tt.expected, results[0].Tags()) {
+				t.Fatalf("expected tags %v, got %v", tt.expected, results)
+			}
+		})
+	}
+}
+
+func TestTagUsages(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"go", "parser"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed pull requests", []string{"Go"}),
+		NewLog(Meta{Date: testDate.AddDate(0, 0, 1), Page: "work"}, "Meeting notes", []string{"go"}),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	expected := []TagUsage{
+		{Name: "Go", Count: 1, LastUsed: testDate.Add(time.Hour)},
+		{Name: "go", Count: 2, LastUsed: testDate.AddDate(0, 0, 1)},
+		{Name: "parser", Count: 1, LastUsed: testDate},
+	}
+
+	actual, err := TagUsages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected tag usages %v did not equal to actual tag usages %v", expected, actual)
+	}
+}
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "Wrote the #parser in #Go", expected: []string{"parser", "Go"}},
+		{text: "#parser and #parser again", expected: []string{"parser"}},
+		{text: "Fixed issue #42", expected: nil},
+		{text: "# Heading\nhttps://example.com/#anchor", expected: nil},
+		{text: "Tagged #work/meetings, #todo-", expected: []string{"work/meetings", "todo"}},
+		{text: "#käyttöliittymä", expected: []string{"käyttöliittymä"}},
+		{text: "```\n#include <stdio.h>\n```\n#c", expected: []string{"c"}},
+		{text: "````\n```\n#include <stdio.h>\n```\n````\n#c", expected: []string{"c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual := hashtags(tt.text)
+
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("expected hashtags %v did not equal to actual hashtags %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"GoLang"}),
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed pull requests", []string{"GoLang", "golang"}),
+		NewLog(Meta{Date: testDate.Add(2 * time.Hour)}, "Meeting notes", []string{"golang"}),
+		NewLog(Meta{Date: testDate.Add(3 * time.Hour)}, "Planning", []string{"GoLang"}),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	tests := []struct {
+		policy     config.TagPolicy
+		log        Log
+		expected   []string
+		expectsErr bool
+	}{
+		{
+			log:      NewLog(Meta{Date: testDate}, "Wrote the #parser", []string{"Go"}),
+			expected: []string{"Go", "parser"},
+		},
+		{
+			log:      NewLog(Meta{Date: testDate}, "Wrote the parser\nin #Go", []string{"Go"}),
+			expected: []string{"Go"},
+		},
+		{
+			policy:   config.TagPolicy{Case: config.TagCaseLower},
+			log:      NewLog(Meta{Date: testDate}, "Wrote the #Parser", []string{"Go", "go"}),
+			expected: []string{"go", "parser"},
+		},
+		{
+			policy:   config.TagPolicy{Case: config.TagCaseExisting},
+			log:      NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"golang", "parser"}),
+			expected: []string{"GoLang", "parser"},
+		},
+		{
+			policy:   config.TagPolicy{Allowed: []string{"Go", "parser"}},
+			log:      NewLog(Meta{Date: testDate}, "Wrote the #PARSER", []string{"go"}),
+			expected: []string{"Go", "parser"},
+		},
+		{
+			policy:     config.TagPolicy{Allowed: []string{"go"}},
+			log:        NewLog(Meta{Date: testDate}, "Wrote the #parser", []string{"go"}),
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			config.Config.Tags = map[string]config.TagPolicy{"test": tt.policy}
+
+			actual, err := normalizeTags(tt.log)
+			if tt.expectsErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr {
+				return
+			}
+
+			if !reflect.DeepEqual(tt.expected, actual.Tags()) {
+				t.Fatalf("expected tags %v did not equal to actual tags %v", tt.expected, actual.Tags())
+			}
+
+			if actual.Summary() != tt.log.Summary() || actual.Body() != tt.log.Body() {
+				t.Fatalf("expected log entry %v to keep its content, got %v", tt.log.Data, actual.Data)
+			}
+		})
+	}
+}
+
+func TestTagNormalizer(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", []string{"GoLang"}),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	config.Config.Tags = map[string]config.TagPolicy{"test": {Case: config.TagCaseExisting}}
+
+	// Tags of the earlier log entries are existing tags for the later ones
+	logs := []Log{
+		NewLog(Meta{Date: testDate.Add(time.Hour)}, "Reviewed the #parser", []string{"golang", "Infra"}),
+		NewLog(Meta{Date: testDate.Add(2 * time.Hour)}, "Deployed", []string{"infra", "Parser"}),
+		NewLog(Meta{Date: testDate.Add(3 * time.Hour)}, "Planning", []string{"PARSER"}),
+	}
+
+	expected := [][]string{
+		{"GoLang", "Infra", "parser"},
+		{"Infra", "parser"},
+		{"parser"},
+	}
+
+	n := newTagNormalizer()
+
+	for i, l := range logs {
+		actual, err := n.normalize(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(expected[i], actual.Tags()) {
+			t.Fatalf("expected tags %v did not equal to actual tags %v", expected[i], actual.Tags())
+		}
+	}
+}
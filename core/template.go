@@ -0,0 +1,198 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+// Templates are markdown files in the templates directory of the workspace,
+// which pre-fill the editor. An optional front matter sets the default page
+// and tags of the log entries written with the template:
+//
+//	---
+//	page: work
+//	tags: [standup]
+//	---
+//	Standup {{date}}
+//	Yesterday: {{last}}
+//	Today:
+const templateExt = ".md"
+
+var (
+	ErrTemplateNotFoundF     = func(name string) error { return fmt.Errorf("template \"%s\" not found in %s", name, templateDir()) }
+	ErrInvalidTemplateNameF  = func(name string) error { return fmt.Errorf("invalid template name \"%s\"", name) }
+	ErrUnknownTemplateValueF = func(p string) error { return fmt.Errorf("unknown template placeholder \"{{%s}}\"", p) }
+	ErrInvalidTemplateF      = func(name string, e error) error { return fmt.Errorf("invalid template \"%s\" - %w", name, e) }
+)
+
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([a-z]+)\s*\}\}`)
+
+// Template is a named log entry template of the workspace.
+type Template struct {
+	Name string
+	// Page is the page of the log entries written with the template when no
+	// page is given
+	Page string
+	// Tags are added to the tags of the log entries written with the template
+	Tags []string
+	// Body is the content pre-filled in the editor before the placeholders
+	// are replaced
+	Body string
+}
+
+// templateDir returns the directory of the templates in the current
+// workspace.
+func templateDir() string {
+	return filepath.Join(config.WorkspacePath(), ".caplog", "templates")
+}
+
+// Templates returns the names of the templates in the current workspace.
+func Templates() ([]string, error) {
+	entries, err := os.ReadDir(templateDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), templateExt) {
+			names = append(names, strings.TrimSuffix(e.Name(), templateExt))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// LoadTemplate reads the template with the name from the current workspace.
+func LoadTemplate(name string) (Template, error) {
+	if len(name) == 0 || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return Template{}, ErrInvalidTemplateNameF(name)
+	}
+
+	b, err := os.ReadFile(filepath.Join(templateDir(), name+templateExt))
+	if os.IsNotExist(err) {
+		return Template{}, ErrTemplateNotFoundF(name)
+	}
+	if err != nil {
+		return Template{}, err
+	}
+
+	t, err := parseTemplate(name, string(b))
+	if err != nil {
+		return Template{}, ErrInvalidTemplateF(name, err)
+	}
+
+	return t, nil
+}
+
+// parseTemplate parses the front matter and the body of the template.
+func parseTemplate(name string, content string) (Template, error) {
+	t := Template{Name: name, Body: content}
+
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], "\r") != frontMatterDelimiter {
+		return t, nil
+	}
+
+	// key is the latest key, which owns the list items below it
+	var key string
+
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+
+		if line == frontMatterDelimiter {
+			t.Body = strings.Join(lines[i+1:], "\n")
+			return t, nil
+		}
+
+		if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "-") {
+			if item, ok := yamlListItem(line); ok && key == tagsKey {
+				t.Tags = appendUnique(t.Tags, item)
+			}
+			continue
+		}
+
+		k, v, _ := strings.Cut(line, ":")
+		key = strings.TrimSpace(k)
+
+		switch key {
+		case pageKey:
+			t.Page = parseYAMLScalar(v)
+		case tagsKey:
+			t.Tags = appendUnique(t.Tags, parseYAMLFlowList(v)...)
+		}
+	}
+
+	return t, ErrMissingFrontMatter
+}
+
+// Render replaces the placeholders of the template with the values of the
+// log entry written with it:
+//
+//	{{date}}       date of the log entry
+//	{{time}}       time of the log entry
+//	{{workspace}}  current workspace
+//	{{page}}       page of the log entry
+//	{{last}}       summary of the latest log entry in the page
+//	{{author}}     git user of the workspace
+func (t Template) Render(meta Meta) (string, error) {
+	var err error
+
+	rendered := templatePlaceholder.ReplaceAllStringFunc(t.Body, func(m string) string {
+		name := templatePlaceholder.FindStringSubmatch(m)[1]
+
+		v, e := templateValue(name, meta)
+		if e != nil && err == nil {
+			err = e
+		}
+
+		return v
+	})
+
+	return rendered, err
+}
+
+func templateValue(name string, meta Meta) (string, error) {
+	switch name {
+	case "date":
+		return formatDate(meta.Date), nil
+	case "time":
+		return meta.Date.Format(config.TimeFormat()), nil
+	case "workspace":
+		return config.Config.CurrentWorkspace, nil
+	case "page":
+		return meta.Page, nil
+	case "last":
+		ref, err := findLastLog(meta.Page)
+		if errors.Is(err, ErrNoLogs) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return ref.log().Summary(), nil
+	case "author":
+		// Author is left empty like in the front matter when git has no user
+		author, _ := git.Author(config.WorkspacePath())
+		return author, nil
+	}
+
+	return "", ErrUnknownTemplateValueF(name)
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/git"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		content    string
+		expected   Template
+		expectsErr bool
+	}{
+		{
+			content:  "Standup\n\nToday:\n",
+			expected: Template{Name: "standup", Body: "Standup\n\nToday:\n"},
+		},
+		{
+			content:  "---\npage: work\ntags: [standup, team]\n---\nStandup\n",
+			expected: Template{Name: "standup", Page: "work", Tags: []string{"standup", "team"}, Body: "Standup\n"},
+		},
+		{
+			content:  "---\n# Daily standup\ntags:\n  - standup\n  - team\nowner: me\n---\nStandup\n",
+			expected: Template{Name: "standup", Tags: []string{"standup", "team"}, Body: "Standup\n"},
+		},
+		{
+			content:    "---\npage: work\nStandup\n",
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := parseTemplate("standup", tt.content)
+			if tt.expectsErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr {
+				return
+			}
+
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("expected template %+v did not equal to actual template %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	dir := testWorkspace(t,
+		NewLog(Meta{Date: testDate}, "Wrote the parser", nil),
+		NewLog(Meta{Date: testDate.Add(time.Hour), Page: "work"}, "Reviewed pull requests", nil),
+	)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	author, err := git.Author(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := Meta{Date: testDate.AddDate(0, 0, 1), Page: "work"}
+
+	tests := []struct {
+		body       string
+		meta       Meta
+		expected   string
+		expectsErr bool
+	}{
+		{
+			body:     "Standup {{date}} {{time}}\nYesterday: {{last}}\n",
+			meta:     meta,
+			expected: "Standup Tuesday, May 17, 2022 19:20\nYesterday: Reviewed pull requests\n",
+		},
+		{
+			body:     "{{ workspace }}/{{page}} by {{author}}",
+			meta:     meta,
+			expected: "test/work by " + author,
+		},
+		{
+			body:     "Yesterday: {{last}}",
+			meta:     Meta{Date: testDate, Page: "empty"},
+			expected: "Yesterday: ",
+		},
+		{
+			body:     "Code {{ .Date }}",
+			meta:     meta,
+			expected: "Code {{ .Date }}",
+		},
+		{
+			body:       "Standup {{weekday}}",
+			meta:       meta,
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := Template{Body: tt.body}.Render(tt.meta)
+			if tt.expectsErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectsErr, err)
+			}
+
+			if !tt.expectsErr && tt.expected != actual {
+				t.Fatalf("expected rendered template %q did not equal to actual %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer testConfig(t, dir, "")()
+
+	if err := os.MkdirAll(templateDir(), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(templateDir(), "standup.md"), []byte("---\ntags: [standup]\n---\nStandup\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		expected   Template
+		expectsErr bool
+	}{
+		{name: "standup", expected: Template{Name: "standup", Tags: []string{"standup"}, Body: "Standup\n"}},
+		{name: "incident", expectsErr: true},
+		{name: "../standup", expectsErr: true},
+		{name: "", expectsErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := LoadTemplate(tt.name)
+			if tt.expectsErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectsErr, err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("expected template %+v did not equal to actual template %+v", tt.expected, actual)
+			}
+		})
+	}
+
+	names, err := Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual([]string{"standup"}, names) {
+		t.Fatalf("expected templates [standup], got %v", names)
+	}
+}
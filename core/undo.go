@@ -0,0 +1,169 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/git"
+)
+
+// Commit message prefixes of changes made by caplog, log entries are
+// committed with the log entry itself as the commit message except in
+// encrypted workspaces
+const (
+	logCommitPrefix    = "log: "
+	editCommitPrefix   = "edit: "
+	importCommitPrefix = "import: "
+	removeCommitPrefix = "rm: "
+	undoCommitPrefix   = "undo: "
+	tagCommitPrefix    = "tag: "
+	// Layout migrations are not undone, they are changed back by migrating
+	// to the previous layout
+	migrateCommitPrefix = "migrate: "
+)
+
+// Number of latest commits searched for a commit to undo
+const undoCommitDepth = 100
+
+var (
+	ErrNothingToUndo = errors.New("no caplog commits to undo")
+	ErrUndoMigration = errors.New("cannot undo changes made before the layout migration")
+)
+
+var revertedCommit = regexp.MustCompile(`This reverts commit ([0-9a-f]+)\.`)
+
+// isCaplogCommit reports whether the commit in the workspace in root is made
+// by caplog. Commits of the project or of other workspaces in the same
+// repository are not caplog commits of the workspace even when their commit
+// message looks like one.
+func isCaplogCommit(root string, c git.Commit) bool {
+	if !isCaplogMessage(c.Message) {
+		return false
+	}
+
+	files, err := git.CommitFilesOf(root, c.Hash)
+	if err != nil {
+		return false
+	}
+
+	return isWorkspaceFiles(files)
+}
+
+// isWorkspaceFiles reports whether all files are log files in the layout of
+// the current workspace or files caplog keeps next to them. The files are
+// relative to the workspace.
+func isWorkspaceFiles(files []string) bool {
+	if len(files) == 0 {
+		return false
+	}
+
+	layout := config.WorkspaceLayout()
+	for _, f := range files {
+		if f == signaturesFilename || f == manifestFilename {
+			continue
+		}
+
+		if _, _, ok := layout.Match(f); !ok || strings.HasPrefix(f, "../") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isCaplogMessage reports whether the commit message is from a commit made
+// by caplog.
+func isCaplogMessage(msg string) bool {
+	for _, prefix := range []string{logCommitPrefix, editCommitPrefix, importCommitPrefix, removeCommitPrefix, undoCommitPrefix, tagCommitPrefix} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+
+	summary, _, _ := strings.Cut(msg, "\n")
+	_, _, ok := parseEntryHeader(summary)
+
+	return ok
+}
+
+// UndoLog reverts the latest commit made by caplog in the current workspace
+// with a new commit. Commits that are already undone are skipped, so undoing
+// repeatedly reverts earlier and earlier commits.
+func UndoLog(out io.Writer) error {
+	root := config.WorkspacePath()
+
+	commits, err := git.Commits(root, undoCommitDepth)
+	if err != nil {
+		return ErrNothingToUndo
+	}
+
+	undone := map[string]bool{}
+
+	for _, c := range commits {
+		if strings.HasPrefix(c.Message, undoCommitPrefix) {
+			if m := revertedCommit.FindStringSubmatch(c.Message); m != nil {
+				undone[m[1]] = true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(c.Message, migrateCommitPrefix) {
+			return ErrUndoMigration
+		}
+
+		if undone[c.Hash] || !isCaplogCommit(root, c) {
+			continue
+		}
+
+		summary, _, _ := strings.Cut(c.Message, "\n")
+		msg := fmt.Sprintf("%s%s\n\nThis reverts commit %s.", undoCommitPrefix, summary, c.Hash)
+
+		if err := git.Revert(root, c.Hash, msg); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "undid \"%s\"", summary)
+
+		return updateIndex(root)
+	}
+
+	return ErrNothingToUndo
+}
+
+// RemoveLog removes the nth log entry written at the given date from the
+// given page in the current workspace. The log file is removed when it has no
+// log entries left.
+func RemoveLog(out io.Writer, page string, date time.Time, n int) error {
+	ref, err := findLog(page, date, n)
+	if err != nil {
+		return err
+	}
+
+	msg := commitMessage(removeCommitPrefix, fmt.Sprintf("%s\n\n%s", ref.ref(), formatLog(ref.log())))
+
+	logs := append(ref.logs[:ref.i:ref.i], ref.logs[ref.i+1:]...)
+
+	if len(logs) == 0 {
+		err = os.Remove(ref.path)
+	} else {
+		err = writeFile(ref.path, []byte(formatLogFile(logs)))
+	}
+	if err != nil {
+		return err
+	}
+
+	signatures, err := signLogs(nil, []Log{ref.log()})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "removed log entry from %s", ref.path)
+
+	return commit(msg, time.Time{}, append([]string{ref.path}, signatures...)...)
+}
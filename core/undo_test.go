@@ -0,0 +1,185 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/git"
+)
+
+func TestIsCaplogMessage(t *testing.T) {
+	tests := []struct {
+		msg      string
+		expected bool
+	}{
+		{msg: "19:20\tWrote the parser\n\ttags: go", expected: true},
+		{msg: "edit: 2022-05-16 19:20 Wrote the parser", expected: true},
+		{msg: "import: 2 log entries from jrnl", expected: true},
+		{msg: "rm: work 2022-05-16 19:20 Meeting notes", expected: true},
+		{msg: "undo: rm: 2022-05-16 19:20 Wrote the parser", expected: true},
+		{msg: "tag: rename golang to go in 3 log entries", expected: true},
+		{msg: "Add README", expected: false},
+		{msg: "16-05-2022.log.md", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if actual := isCaplogMessage(tt.msg); actual != tt.expected {
+				t.Fatalf("expected %t for %q, got %t", tt.expected, tt.msg, actual)
+			}
+		})
+	}
+}
+
+func TestIsWorkspaceFiles(t *testing.T) {
+	defer testConfig(t, os.TempDir(), "")()
+
+	tests := []struct {
+		files    []string
+		expected bool
+	}{
+		{files: []string{"16-05-2022.log.md"}, expected: true},
+		{files: []string{"work/16-05-2022.log.md", ".caplog/signatures", ".caplog/manifest"}, expected: true},
+		{files: []string{"16-05-2022.log.md", "README.md"}, expected: false},
+		{files: []string{"../second/16-05-2022.log.md"}, expected: false},
+		{files: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if actual := isWorkspaceFiles(tt.files); actual != tt.expected {
+				t.Fatalf("expected %t for %q, got %t", tt.expected, tt.files, actual)
+			}
+		})
+	}
+}
+
+func TestUndoSharedRepository(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	// Local workspaces of two projects in the same repository as the projects
+	first := NewLog(Meta{Date: testDate, Page: "first"}, "Wrote the parser", nil)
+	second := NewLog(Meta{Date: testDate, Page: "second"}, "Reviewed the parser", nil)
+
+	dir := testWorkspace(t, first, second)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	remove := func(workspace string) {
+		defer testConfig(t, filepath.Join(dir, workspace), "")()
+
+		if err := RemoveLog(io.Discard, "", testDate, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remove("first")
+	remove("second")
+
+	// Project commits with messages looking like caplog commits
+	for _, f := range []string{"README.md", "first/README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte("readme"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := git.CommitFiles(dir, []string{f}, "edit: readme wording"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer testConfig(t, filepath.Join(dir, "first"), "")()
+
+	if err := UndoLog(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"README.md", "first/README.md", "first/16-05-2022.log.md"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Fatalf("expected %s to exist, got %v", f, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "second", "16-05-2022.log.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected log entry of the second workspace to stay removed, got %v", err)
+	}
+
+	if err := UndoLog(io.Discard); err != ErrNothingToUndo {
+		t.Fatalf("expected error %v, got %v", ErrNothingToUndo, err)
+	}
+}
+
+func TestRemoveAndUndoLog(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	first := NewLog(Meta{Date: testDate}, "First entry", []string{"tag0"})
+	second := NewLog(Meta{Date: testDate.Add(time.Hour)}, "Second entry", nil)
+
+	dir := testWorkspace(t, first, second)
+	defer os.RemoveAll(dir)
+
+	commitWorkspace(t, dir)
+
+	defer testConfig(t, dir, "")()
+
+	path := dir + "/16-05-2022.log.md"
+
+	tests := []struct {
+		run        func() error
+		expected   []Log
+		expectsErr bool
+	}{
+		{
+			run:        func() error { return RemoveLog(io.Discard, "", testDate.Add(time.Minute), 1) },
+			expected:   []Log{first, second},
+			expectsErr: true,
+		},
+		{
+			run:      func() error { return RemoveLog(io.Discard, "", testDate, 1) },
+			expected: []Log{second},
+		},
+		{
+			run: func() error { return RemoveLog(io.Discard, "", testDate.Add(time.Hour), 1) },
+		},
+		{
+			run:      func() error { return UndoLog(io.Discard) },
+			expected: []Log{second},
+		},
+		{
+			run:      func() error { return UndoLog(io.Discard) },
+			expected: []Log{first, second},
+		},
+		{
+			run:        func() error { return UndoLog(io.Discard) },
+			expected:   []Log{first, second},
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			err := tt.run()
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			actual, err := ReadLogFile(path)
+			if len(tt.expected) == 0 {
+				if !os.IsNotExist(err) {
+					t.Fatalf("expected log file to be removed, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if formatLogFile(tt.expected) != formatLogFile(actual) {
+				t.Fatalf("expected log file:\n%s\ndid not match actual log file:\n%s", formatLogFile(tt.expected), formatLogFile(actual))
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// Encrypted files are armored as base64 text between the header and the
+// footer lines, so they can be stored and diffed as text in git.
+const (
+	armorHeader = "-----BEGIN CAPLOG ENCRYPTED FILE-----"
+	armorFooter = "-----END CAPLOG ENCRYPTED FILE-----"
+	lineLength  = 64
+)
+
+const (
+	version    = 1
+	SaltSize   = 16
+	keySize    = 32
+	iterations = 600000
+)
+
+var (
+	ErrEmptyPassphrase = errors.New("passphrase cannot be empty")
+	ErrInvalidSalt     = errors.New("invalid salt size")
+	ErrNotEncrypted    = errors.New("file is not encrypted")
+	ErrUnknownVersion  = errors.New("unknown encryption version")
+	ErrDecrypt         = errors.New("failed to decrypt, either the passphrase is wrong or the file is modified")
+)
+
+// Keyring derives keys from a passphrase and caches the derived keys by
+// salt, so the slow key derivation is done only once for each salt.
+type Keyring struct {
+	passphrase []byte
+	keys       map[string][]byte
+}
+
+func NewKeyring(passphrase []byte) (*Keyring, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrEmptyPassphrase
+	}
+
+	return &Keyring{passphrase: passphrase, keys: map[string][]byte{}}, nil
+}
+
+// NewSalt returns a new random salt for deriving keys.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+func (k *Keyring) key(salt []byte) []byte {
+	if key, ok := k.keys[string(salt)]; ok {
+		return key
+	}
+
+	key := pbkdf2(k.passphrase, salt, iterations, keySize)
+	k.keys[string(salt)] = key
+
+	return key
+}
+
+// Encrypt encrypts and authenticates the plaintext with a key derived from
+// the salt. The salt is stored with the ciphertext.
+func (k *Keyring) Encrypt(plaintext []byte, salt []byte) ([]byte, error) {
+	if len(salt) != SaltSize {
+		return nil, ErrInvalidSalt
+	}
+
+	aead, err := newAEAD(k.key(salt))
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{version}, salt...)
+	sealed := aead.Seal(append(header, nonce...), nonce, plaintext, header)
+
+	return armor(sealed), nil
+}
+
+// Decrypt decrypts data encrypted with Encrypt.
+func (k *Keyring) Decrypt(data []byte) ([]byte, error) {
+	sealed, err := unarmor(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < 1+SaltSize {
+		return nil, ErrDecrypt
+	}
+
+	if sealed[0] != version {
+		return nil, ErrUnknownVersion
+	}
+
+	header, rest := sealed[:1+SaltSize], sealed[1+SaltSize:]
+
+	aead, err := newAEAD(k.key(header[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// IsEncrypted reports whether the data is an encrypted file.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(armorHeader))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func armor(b []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(b)
+
+	var sb strings.Builder
+	sb.WriteString(armorHeader + "\n")
+	for len(encoded) > lineLength {
+		sb.WriteString(encoded[:lineLength] + "\n")
+		encoded = encoded[lineLength:]
+	}
+	sb.WriteString(encoded + "\n")
+	sb.WriteString(armorFooter + "\n")
+
+	return []byte(sb.String())
+}
+
+func unarmor(data []byte) ([]byte, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	if len(lines) < 2 || lines[0] != armorHeader || lines[len(lines)-1] != armorFooter {
+		return nil, ErrNotEncrypted
+	}
+
+	b, err := base64.StdEncoding.DecodeString(strings.Join(lines[1:len(lines)-1], ""))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return b, nil
+}
+
+// pbkdf2 derives a key from the password with PBKDF2 using HMAC-SHA256 as
+// described in RFC 8018.
+func pbkdf2(password []byte, salt []byte, iter int, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+
+	var key []byte
+	block := make([]byte, 4)
+
+	for i := uint32(1); len(key) < keyLen; i++ {
+		binary.BigEndian.PutUint32(block, i)
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(block)
+		u := prf.Sum(nil)
+
+		t := make([]byte, len(u))
+		copy(t, u)
+
+		for n := 1; n < iter; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:keyLen]
+}
@@ -0,0 +1,113 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256 from RFC 7914
+	tests := []struct {
+		password string
+		salt     string
+		iter     int
+		keyLen   int
+		expected string
+	}{
+		{
+			password: "passwd",
+			salt:     "salt",
+			iter:     1,
+			keyLen:   64,
+			expected: "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783",
+		},
+		{
+			password: "Password",
+			salt:     "NaCl",
+			iter:     80000,
+			keyLen:   64,
+			expected: "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual := hex.EncodeToString(pbkdf2([]byte(tt.password), []byte(tt.salt), tt.iter, tt.keyLen))
+			if tt.expected != actual {
+				t.Fatalf("expected key %s did not match actual %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring, err := NewKeyring([]byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	salt, err := NewSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("---\ndate: Monday, May 16, 2022\n---\n\n19:20\tWrote the parser\n")
+
+	encrypted, err := keyring.Encrypt(plaintext, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsEncrypted(encrypted) || bytes.Contains(encrypted, []byte("parser")) {
+		t.Fatalf("expected encrypted file, got %s", encrypted)
+	}
+
+	wrongKeyring, err := NewKeyring([]byte("wrong"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modified := bytes.Replace(encrypted, []byte("\n"), []byte("\nA"), 2)
+
+	tests := []struct {
+		keyring    *Keyring
+		input      []byte
+		expected   []byte
+		expectsErr bool
+	}{
+		{
+			keyring:  keyring,
+			input:    encrypted,
+			expected: plaintext,
+		},
+		{
+			keyring:    wrongKeyring,
+			input:      encrypted,
+			expectsErr: true,
+		},
+		{
+			keyring:    keyring,
+			input:      modified,
+			expectsErr: true,
+		},
+		{
+			keyring:    keyring,
+			input:      plaintext,
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := tt.keyring.Decrypt(tt.input)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if !bytes.Equal(tt.expected, actual) {
+				t.Fatalf("expected plaintext %q did not match actual %q", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package crypt
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+)
+
+var (
+	ErrInvalidSigningKey = errors.New("signing key must be an ed25519 private or public key in PEM format")
+	ErrNoPrivateKey      = errors.New("signing needs a private key, only a public key was given")
+)
+
+// SigningKey is an ed25519 key pair used for signing log entries. Private
+// key is nil when only the public key is known, which is enough for
+// verifying signatures.
+type SigningKey struct {
+	Public  ed25519.PublicKey
+	Private ed25519.PrivateKey
+}
+
+// ReadSigningKey reads either a PKCS #8 private key or a PKIX public key in
+// PEM format from the given path.
+func ReadSigningKey(path string) (SigningKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	return ParseSigningKey(b)
+}
+
+func ParseSigningKey(b []byte) (SigningKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return SigningKey{}, ErrInvalidSigningKey
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, ErrInvalidSigningKey
+		}
+
+		private, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return SigningKey{}, ErrInvalidSigningKey
+		}
+
+		return SigningKey{Public: private.Public().(ed25519.PublicKey), Private: private}, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return SigningKey{}, ErrInvalidSigningKey
+		}
+
+		public, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return SigningKey{}, ErrInvalidSigningKey
+		}
+
+		return SigningKey{Public: public}, nil
+	}
+
+	return SigningKey{}, ErrInvalidSigningKey
+}
+
+// Sign signs the message with the private key.
+func (k SigningKey) Sign(msg []byte) ([]byte, error) {
+	if k.Private == nil {
+		return nil, ErrNoPrivateKey
+	}
+
+	return ed25519.Sign(k.Private, msg), nil
+}
+
+// Verify reports whether the signature of the message is valid.
+func (k SigningKey) Verify(msg []byte, sig []byte) bool {
+	return ed25519.Verify(k.Public, msg, sig)
+}
@@ -0,0 +1,72 @@
+package crypt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestParseSigningKey(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input       []byte
+		expectsSign bool
+		expectsErr  bool
+	}{
+		{
+			input:       pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+			expectsSign: true,
+		},
+		{
+			input: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}),
+		},
+		{
+			input:      pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: publicDER}),
+			expectsErr: true,
+		},
+		{
+			input:      []byte("not a key"),
+			expectsErr: true,
+		},
+	}
+
+	msg := []byte("19:20\tWrote the parser")
+	sig := ed25519.Sign(private, msg)
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			key, err := ParseSigningKey(tt.input)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if tt.expectsErr {
+				return
+			}
+
+			if !key.Verify(msg, sig) {
+				t.Fatal("expected signature to be valid")
+			}
+
+			if _, err := key.Sign(msg); (err == nil) != tt.expectsSign {
+				t.Fatalf("expects signing %t did not match actual %v", tt.expectsSign, err)
+			}
+		})
+	}
+}
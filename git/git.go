@@ -6,75 +6,305 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 var (
 	ErrNoPathProvided        = errors.New("no path provided")
 	ErrGitExecNotFoundInPath = errors.New("git executable not found in path")
 	ErrGitCommit             = func(e error) error { return fmt.Errorf("failed to commit - %w", e) }
+	ErrNoRemote              = errors.New("repository has no remote")
 )
 
-func hasGitRemote() bool {
-	if err := runGitCommand("ls-remote"); err != nil {
+func hasGitRemote(path string) bool {
+	if err := runGitCommand("-C", path, "ls-remote"); err != nil {
 		return false
 	}
 
 	return true
 }
 
+// isGitRepository reports whether the path is inside a git work tree.
 func isGitRepository(path string) bool {
-	// Check if git directory exists
-	if _, err := os.Stat(fmt.Sprintf("%s/.git", path)); os.IsNotExist(err) {
+	if out, err := gitOutput("-C", path, "rev-parse", "--is-inside-work-tree"); err != nil || out != "true" {
 		return false
 	}
 
-	// TODO: think of a better command to validate git repository
-	if err := runGitCommand("-C", path, "rev-parse"); err != nil {
-		return false
+	return true
+}
+
+// Init initializes a git repository in the given path if the path does not
+// have one already. Sub-directories of the path are then part of the same
+// repository.
+func Init(path string) error {
+	if _, err := os.Stat(fmt.Sprintf("%s/.git", path)); !os.IsNotExist(err) {
+		return nil
 	}
 
-	return true
+	return runGitCommand("init", "-q", "-b", "trunk", path)
+}
+
+// Clone clones the repository from the url to the given path.
+func Clone(url string, path string) error {
+	return runGitCommand("clone", "-q", url, path)
 }
 
-func CommitSingleFile(path string, msg string) error {
-	if len(path) == 0 {
+// IsRepository reports whether the path is inside a git work tree.
+func IsRepository(path string) bool {
+	return isGitRepository(path)
+}
+
+// RemoteURL returns the url of the origin remote of the repository in the
+// given path.
+func RemoteURL(path string) (string, error) {
+	return gitOutput("-C", path, "remote", "get-url", "origin")
+}
+
+// CommitFiles commits all given files in a single commit to the repository
+// in the given path. Files that were removed are committed as deletions.
+func CommitFiles(path string, files []string, msg string) error {
+	return CommitFilesAt(path, files, msg, time.Time{})
+}
+
+// CommitFilesAt commits the files with the given author date, which is the
+// current time when the date is zero.
+func CommitFilesAt(path string, files []string, msg string, date time.Time) error {
+	if len(files) == 0 {
 		return ErrGitCommit(ErrNoPathProvided)
 	}
 
-	dirpath := filepath.Dir(path)
-	if !isGitRepository(dirpath) {
-		if err := runGitCommand("init", "-q", "-b", "trunk", dirpath); err != nil {
+	if !isGitRepository(path) {
+		if err := Init(path); err != nil {
 			return ErrGitCommit(err)
 		}
 	}
 
-	if err := os.Chdir(dirpath); err != nil {
+	args := append([]string{"-C", path, "add", "--all", "--"}, files...)
+	if err := runGitCommand(args...); err != nil {
 		return ErrGitCommit(err)
 	}
 
-	if err := runGitCommand("add", path); err != nil {
+	args = []string{"-C", path, "commit", "-m", msg}
+	if !date.IsZero() {
+		args = append(args, "--date", date.Format(time.RFC3339))
+	}
+
+	if err := runGitCommand(append(append(args, "--"), files...)...); err != nil {
 		return ErrGitCommit(err)
 	}
 
-	if err := runGitCommand("commit", "-m", msg, path); err != nil {
+	if err := syncRemote(path); err != nil {
 		return ErrGitCommit(err)
 	}
 
-	if hasGitRemote() {
-		// TODO: adjust with flag or configuration
-		// TODO: think about detached process ordering and composition
-		if err := runGitCommand("pull", "--rebase=merges"); err != nil {
-			return ErrGitCommit(err)
+	return nil
+}
+
+// syncRemote pulls and pushes the changes if the repository has a remote.
+func syncRemote(path string) error {
+	if !hasGitRemote(path) {
+		return nil
+	}
+
+	// TODO: adjust with flag or configuration
+	// TODO: think about detached process ordering and composition
+	if err := runGitCommand("-C", path, "pull", "--rebase=merges"); err != nil {
+		return err
+	}
+
+	return runDetachedGitCommand("-C", path, "push", "--force-with-lease")
+}
+
+// Sync pulls the changes from the remote and pushes the local commits to the
+// remote of the repository in the given path.
+func Sync(path string) error {
+	if !hasGitRemote(path) {
+		return ErrNoRemote
+	}
+
+	if err := runGitCommand("-C", path, "pull", "--rebase=merges"); err != nil {
+		return err
+	}
+
+	return runGitCommand("-C", path, "push", "--force-with-lease")
+}
+
+// HeadCommit returns the commit hash of HEAD in the repository of the given path.
+func HeadCommit(path string) (string, error) {
+	return gitOutput("-C", path, "rev-parse", "HEAD")
+}
+
+// Author returns the name of the author of the commits made in the
+// repository of the given path.
+func Author(path string) (string, error) {
+	ident, err := gitOutput("-C", path, "var", "GIT_AUTHOR_IDENT")
+	if err != nil {
+		return "", err
+	}
+
+	name, _, _ := strings.Cut(ident, " <")
+
+	return name, nil
+}
+
+// Dir returns the absolute path to the .git directory of the repository
+// in the given path.
+func Dir(path string) (string, error) {
+	return gitOutput("-C", path, "rev-parse", "--absolute-git-dir")
+}
+
+// ChangedFiles returns the files changed between two commits relative to the
+// given path.
+func ChangedFiles(path string, from string, to string) ([]string, error) {
+	out, err := gitOutput("-c", "core.quotePath=false", "-C", path, "diff", "--name-only", "--relative", from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitLines(out), nil
+}
+
+// UncommittedFiles returns the files which differ from the HEAD commit,
+// untracked files included, relative to the given path.
+func UncommittedFiles(path string) ([]string, error) {
+	changed, err := gitOutput("-c", "core.quotePath=false", "-C", path, "diff", "--name-only", "--relative", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := gitOutput("-c", "core.quotePath=false", "-C", path, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(splitLines(changed), splitLines(untracked)...), nil
+}
+
+// TrackedFiles returns all files tracked by git relative to the given path.
+func TrackedFiles(path string) ([]string, error) {
+	out, err := gitOutput("-c", "core.quotePath=false", "-C", path, "ls-files")
+	if err != nil {
+		return nil, err
+	}
+
+	return splitLines(out), nil
+}
+
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// FileCommits returns the commits which changed the given file starting from
+// the latest commit.
+func FileCommits(path string) ([]Commit, error) {
+	return logCommits("-C", filepath.Dir(path), "log", "--format=%H%x00%B%x1e", "--", filepath.Base(path))
+}
+
+// FileAt returns the content of the file in the given path at the given
+// commit.
+func FileAt(path string, hash string) ([]byte, error) {
+	git, err := execCommand("git", "-C", filepath.Dir(path), "show", fmt.Sprintf("%s:./%s", hash, filepath.Base(path)))
+	if err != nil {
+		return nil, err
+	}
+
+	return git.Output()
+}
+
+// Commits returns at most n latest commits which changed files in the given
+// path starting from the latest commit.
+func Commits(path string, n int) ([]Commit, error) {
+	return logCommits("-C", path, "log", fmt.Sprintf("--max-count=%d", n), "--format=%H%x00%B%x1e", "--", ".")
+}
+
+// CommitFilesOf returns the files changed in the given commit relative to the
+// given path. Files outside the path are relative to it with a leading "../".
+func CommitFilesOf(path string, hash string) ([]string, error) {
+	prefix, err := gitOutput("-C", path, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := gitOutput("-c", "core.quotePath=false", "-C", path, "diff-tree", "--root", "--no-commit-id", "--name-only", "-r", hash)
+	if err != nil {
+		return nil, err
+	}
+
+	files := splitLines(out)
+	for i, f := range files {
+		rel, err := filepath.Rel(filepath.FromSlash(prefix), filepath.FromSlash(f))
+		if err != nil {
+			return nil, err
 		}
+		files[i] = filepath.ToSlash(rel)
+	}
 
-		if err := runDetachedGitCommand("push", "--force-with-lease"); err != nil {
-			return ErrGitCommit(err)
+	return files, nil
+}
+
+func logCommits(args ...string) ([]Commit, error) {
+	out, err := gitOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, raw := range strings.Split(out, "\x1e") {
+		hash, msg, ok := strings.Cut(strings.TrimSpace(raw), "\x00")
+		if !ok {
+			continue
 		}
+		commits = append(commits, Commit{Hash: hash, Message: strings.TrimSpace(msg)})
+	}
+
+	return commits, nil
+}
+
+// Revert reverts the changes of the given commit in a new commit with the
+// given message. Only the files changed in the reverted commit are committed,
+// so changes staged by others are left staged. Nothing is changed if the
+// commit cannot be reverted cleanly.
+func Revert(path string, hash string, msg string) error {
+	// Reverting can remove the path when it is a sub-directory of the
+	// repository, so the commands are run in the top level directory
+	top, err := gitOutput("-C", path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ErrGitCommit(err)
+	}
+
+	files, err := gitOutput("-c", "core.quotePath=false", "-C", top, "diff-tree", "--root", "--no-commit-id", "--name-only", "-r", hash)
+	if err != nil {
+		return ErrGitCommit(err)
+	}
+
+	if err := runGitCommand("-C", top, "revert", "--no-commit", hash); err != nil {
+		runGitCommand("-C", top, "revert", "--abort")
+		return ErrGitCommit(err)
+	}
+
+	args := append([]string{"-C", top, "commit", "-m", msg, "--"}, splitLines(files)...)
+	if err := runGitCommand(args...); err != nil {
+		runGitCommand("-C", top, "revert", "--abort")
+		return ErrGitCommit(err)
+	}
+
+	if err := syncRemote(top); err != nil {
+		return ErrGitCommit(err)
 	}
 
 	return nil
 }
 
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
 func commandExists(command string) bool {
 	if _, err := exec.LookPath(command); err == nil {
 		return true
@@ -108,6 +338,20 @@ func runDetachedGitCommand(args ...string) error {
 	return nil
 }
 
+func gitOutput(args ...string) (string, error) {
+	git, err := execCommand("git", args...)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := git.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
 func runGitCommand(args ...string) error {
 	git, err := execCommand("git", args...)
 	if err != nil {
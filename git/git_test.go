@@ -3,6 +3,8 @@ package git
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -39,35 +41,259 @@ func TestIsGitRepository(t *testing.T) {
 	}
 }
 
-func TestCommitSingleFile(t *testing.T) {
-	dir, _ := os.MkdirTemp("", "caplog")
-	defer os.RemoveAll(dir)
+func TestCommitFiles(t *testing.T) {
+	dir, cleanup := testRepo()
+	defer cleanup()
+
+	os.WriteFile(fmt.Sprintf("%s/%s", dir, "a.log"), []byte("a"), 0644)
+	os.WriteFile(fmt.Sprintf("%s/%s", dir, "b.log"), []byte("b"), 0644)
 
 	tests := []struct {
-		file       string
+		files      []string
 		expectsErr bool
 	}{
 		{
-			file:       "",
+			files:      nil,
 			expectsErr: true,
 		},
 		{
-			file:       dir,
-			expectsErr: true,
+			files:      []string{"a.log", "b.log"},
+			expectsErr: false,
 		},
 		{
-			file:       fmt.Sprintf("%s/%s", dir, "12345_file.log"),
-			expectsErr: false,
+			// Nothing to commit anymore
+			files:      []string{"a.log"},
+			expectsErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			os.Create(tt.file)
-			actual := CommitSingleFile(tt.file, "log: entry")
+			actual := CommitFiles(dir, tt.files, "log: entries")
 			if (actual != nil) != tt.expectsErr {
 				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, actual)
 			}
 		})
 	}
+
+	files, err := TrackedFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("expected both files to be committed, got %v", files)
+	}
+}
+
+func TestRevert(t *testing.T) {
+	dir, cleanup := testRepo()
+	defer cleanup()
+
+	file := fmt.Sprintf("%s/%s", dir, "a.log")
+	os.WriteFile(file, []byte("a"), 0644)
+
+	if err := CommitFiles(dir, []string{"a.log"}, "log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := Commits(dir, 1)
+	if err != nil || len(commits) != 1 {
+		t.Fatalf("expected a commit, got %v %v", commits, err)
+	}
+
+	// Changes staged by others are not part of the revert commit
+	staged := fmt.Sprintf("%s/%s", dir, "b.txt")
+	os.WriteFile(staged, []byte("b"), 0644)
+
+	if err := runGitCommand("-C", dir, "add", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Revert(dir, commits[0].Hash, "undo: log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatal("expected reverted file to be removed")
+	}
+
+	if files, err := TrackedFiles(dir); err != nil || len(files) != 1 || files[0] != "b.txt" {
+		t.Fatalf("expected staged file to stay in the index, got %v %v", files, err)
+	}
+
+	if files, err := ChangedFiles(dir, "HEAD~1", "HEAD"); err != nil || len(files) != 1 || files[0] != "a.log" {
+		t.Fatalf("expected revert commit to only change the reverted file, got %v %v", files, err)
+	}
+
+	commits, err = Commits(dir, 2)
+	if err != nil || len(commits) != 2 || commits[0].Message != "undo: log: entry" {
+		t.Fatalf("expected revert commit, got %v %v", commits, err)
+	}
+
+	if err := Revert(dir, "not-a-commit", "undo"); err == nil {
+		t.Fatal("expected error when reverting unknown commit")
+	}
+}
+
+func TestRevertSubdirectory(t *testing.T) {
+	dir, cleanup := testRepo()
+	defer cleanup()
+
+	sub := fmt.Sprintf("%s/%s", dir, "capbook")
+	os.MkdirAll(sub, 0755)
+	os.WriteFile(sub+"/a.log", []byte("a"), 0644)
+
+	if err := CommitFiles(sub, []string{"a.log"}, "log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := Commits(sub, 1)
+	if err != nil || len(commits) != 1 {
+		t.Fatalf("expected a commit, got %v %v", commits, err)
+	}
+
+	// Reverting the only file of the sub-directory removes the sub-directory
+	if err := Revert(sub, commits[0].Hash, "undo: log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	if commits, err = Commits(dir, 1); err != nil || len(commits) != 1 || commits[0].Message != "undo: log: entry" {
+		t.Fatalf("expected revert commit, got %v %v", commits, err)
+	}
+}
+
+func TestCommitsOfPath(t *testing.T) {
+	dir, cleanup := testRepo()
+	defer cleanup()
+
+	sub := fmt.Sprintf("%s/%s", dir, "capbook")
+	os.MkdirAll(sub, 0755)
+	os.WriteFile(sub+"/a.log", []byte("a"), 0644)
+	os.WriteFile(dir+"/README.md", []byte("readme"), 0644)
+
+	if err := CommitFiles(dir, []string{"capbook/a.log", "README.md"}, "log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(dir+"/README.md", []byte("readme wording"), 0644)
+
+	if err := CommitFiles(dir, []string{"README.md"}, "edit: readme"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Commits of the sub-directory leave out the commits outside of it
+	commits, err := Commits(sub, 10)
+	if err != nil || len(commits) != 1 || commits[0].Message != "log: entry" {
+		t.Fatalf("expected only the commit of the sub-directory, got %v %v", commits, err)
+	}
+
+	files, err := CommitFilesOf(sub, commits[0].Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual([]string{"../README.md", "a.log"}, files) {
+		t.Fatalf("expected files relative to the sub-directory, got %v", files)
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir, cleanup := testRepo()
+	defer cleanup()
+
+	remote, cleanupRemote := testRepo()
+	defer cleanupRemote()
+
+	if err := runGitCommand("-C", remote, "config", "receive.denyCurrentBranch", "ignore"); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(fmt.Sprintf("%s/%s", dir, "a.log"), []byte("a"), 0644)
+
+	if err := runGitCommand("-C", dir, "add", "a.log"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runGitCommand("-C", dir, "commit", "-q", "-m", "log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Sync(dir); err != ErrNoRemote {
+		t.Fatalf("expected %v, got %v", ErrNoRemote, err)
+	}
+
+	if err := runGitCommand("-C", dir, "remote", "add", "origin", remote); err != nil {
+		t.Fatal(err)
+	}
+
+	branch, err := gitOutput("-C", dir, "branch", "--show-current")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runGitCommand("-C", dir, "push", "-q", "-u", "origin", branch); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(fmt.Sprintf("%s/%s", dir, "b.log"), []byte("b"), 0644)
+
+	// Committed without CommitFiles, which would push in the background
+	if err := runGitCommand("-C", dir, "add", "b.log"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runGitCommand("-C", dir, "commit", "-q", "-m", "log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Sync(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	local, _ := HeadCommit(dir)
+	pushed, _ := gitOutput("-C", remote, "rev-parse", branch)
+
+	if local != pushed {
+		t.Fatalf("expected remote to be at %s, got %s", local, pushed)
+	}
+}
+
+func TestClone(t *testing.T) {
+	remote, cleanupRemote := testRepo()
+	defer cleanupRemote()
+
+	os.WriteFile(fmt.Sprintf("%s/%s", remote, "a.log"), []byte("a"), 0644)
+
+	if err := runGitCommand("-C", remote, "add", "a.log"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runGitCommand("-C", remote, "commit", "-q", "-m", "log: entry"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := os.MkdirTemp("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "clone")
+
+	if err := Clone(remote, path); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsRepository(path) {
+		t.Fatalf("expected %s to be a repository", path)
+	}
+
+	if url, err := RemoteURL(path); err != nil || url != remote {
+		t.Fatalf("expected remote url %s, got %s (%v)", remote, url, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(path, "a.log")); err != nil {
+		t.Fatal(err)
+	}
 }
@@ -3,7 +3,6 @@ module github.com/erikjuhani/caplog
 go 1.18
 
 require (
-	github.com/erikjuhani/miniflag v0.0.0-20221003183951-5c5109325bbf
 	github.com/pelletier/go-toml/v2 v2.0.1
 )
 
@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/erikjuhani/caplog/core"
+)
+
+// Supported journal formats
+const (
+	FormatJrnl   = "jrnl"
+	FormatDayOne = "dayone"
+	FormatText   = "txt"
+)
+
+var (
+	ErrUnsupportedFormatF = func(format string) error {
+		return fmt.Errorf("\"%s\" is not a supported import format, supported formats are: %v", format, []string{FormatJrnl, FormatDayOne, FormatText})
+	}
+	ErrInvalidDateF = func(s string) error { return fmt.Errorf("invalid entry date \"%s\"", s) }
+)
+
+var (
+	// [2022-05-16 19:20] Title or 2022-05-16 07:20:00 PM Title
+	jrnlHeader = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2} \d{1,2}:\d{2}(?::\d{2})?(?: [AP]M)?)\]? ?(.*)$`)
+	jrnlTag    = regexp.MustCompile(`(?:^|\s)@([\p{L}\p{N}_-]+)`)
+	// 2022-05-16 19:20 Title or 2022-05-16T19:20 Title
+	textHeader = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}(?::\d{2})?) ?(.*)$`)
+)
+
+var (
+	jrnlLayouts = []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02 03:04 PM", "2006-01-02 03:04:05 PM"}
+	textLayouts = []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02T15:04:05"}
+)
+
+// entry is a journal entry before it is converted to a log entry
+type entry struct {
+	date  time.Time
+	lines []string
+	tags  []string
+}
+
+// Read reads journal entries in the given format and converts them to log
+// entries written to the given page.
+func Read(format string, r io.Reader, page string) ([]core.Log, error) {
+	var (
+		entries []entry
+		err     error
+	)
+
+	switch format {
+	case FormatJrnl:
+		entries, err = readLines(r, jrnlHeader, jrnlLayouts)
+		for i, e := range entries {
+			entries[i].tags = jrnlTags(e.lines)
+		}
+	case FormatText:
+		entries, err = readLines(r, textHeader, textLayouts)
+	case FormatDayOne:
+		entries, err = readDayOne(r)
+	default:
+		return nil, ErrUnsupportedFormatF(format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []core.Log
+	for _, e := range entries {
+		l := core.NewLog(core.Meta{Date: e.date, Page: page}, strings.Join(trimLines(e.lines), "\n"), e.tags)
+		if len(l.Data) == 0 {
+			continue
+		}
+		logs = append(logs, l)
+	}
+
+	return logs, nil
+}
+
+// readLines reads plain text journals where each entry starts with a line
+// beginning with a timestamp followed by the entry title.
+func readLines(r io.Reader, header *regexp.Regexp, layouts []string) ([]entry, error) {
+	var entries []entry
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := header.FindStringSubmatch(line); m != nil {
+			date, err := parseTime(m[1], layouts, time.Local)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, entry{date: date, lines: []string{m[2]}})
+			continue
+		}
+
+		// Text before the first entry is not part of any entry
+		if len(entries) == 0 {
+			continue
+		}
+
+		e := &entries[len(entries)-1]
+		e.lines = append(e.lines, line)
+	}
+
+	return entries, scanner.Err()
+}
+
+type dayOneExport struct {
+	Entries []struct {
+		CreationDate string   `json:"creationDate"`
+		TimeZone     string   `json:"timeZone"`
+		Text         string   `json:"text"`
+		Tags         []string `json:"tags"`
+	} `json:"entries"`
+}
+
+// readDayOne reads entries from a Day One JSON export file.
+func readDayOne(r io.Reader) ([]entry, error) {
+	var export dayOneExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+
+	for _, e := range export.Entries {
+		date, err := time.Parse(time.RFC3339, e.CreationDate)
+		if err != nil {
+			return nil, ErrInvalidDateF(e.CreationDate)
+		}
+
+		// Entries are written in the time zone they were created in
+		if loc, err := time.LoadLocation(e.TimeZone); err == nil && len(e.TimeZone) > 0 {
+			date = date.In(loc)
+		} else {
+			date = date.In(time.Local)
+		}
+
+		entries = append(entries, entry{
+			date:  date,
+			lines: strings.Split(e.Text, "\n"),
+			tags:  e.Tags,
+		})
+	}
+
+	return entries, nil
+}
+
+// jrnlTags returns the @tags used in the jrnl entry in order of appearance.
+func jrnlTags(lines []string) []string {
+	var tags []string
+	seen := map[string]bool{}
+
+	for _, line := range lines {
+		for _, m := range jrnlTag.FindAllStringSubmatch(line, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				tags = append(tags, m[1])
+			}
+		}
+	}
+
+	return tags
+}
+
+func parseTime(s string, layouts []string, loc *time.Location) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidDateF(s)
+}
+
+// trimLines removes empty lines from the start and the end of the entry.
+func trimLines(lines []string) []string {
+	for len(lines) > 0 && len(strings.TrimSpace(lines[0])) == 0 {
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && len(strings.TrimSpace(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/core"
+)
+
+func TestRead(t *testing.T) {
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format     string
+		input      string
+		page       string
+		expected   []core.Log
+		expectsErr bool
+	}{
+		{
+			format: FormatJrnl,
+			input: `[2022-05-16 19:20] Wrote the parser for @caplog.
+It needs tests. @go @caplog
+
+[2022-05-17 08:00] Planned search
+`,
+			expected: []core.Log{
+				core.NewLog(core.Meta{Date: time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)}, "Wrote the parser for @caplog.\nIt needs tests. @go @caplog", []string{"caplog", "go"}),
+				core.NewLog(core.Meta{Date: time.Date(2022, 5, 17, 8, 0, 0, 0, time.Local)}, "Planned search", nil),
+			},
+		},
+		{
+			format: FormatJrnl,
+			input:  "2022-05-16 07:20:00 PM Old jrnl format\n",
+			page:   "journal",
+			expected: []core.Log{
+				core.NewLog(core.Meta{Date: time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local), Page: "journal"}, "Old jrnl format", nil),
+			},
+		},
+		{
+			format: FormatText,
+			input: `Notes before any entry are skipped
+
+2022-05-16 19:20
+Summary on the next line
+
+Body
+tags: tag0, tag1
+2022-05-16T21:00 Second entry
+`,
+			expected: []core.Log{
+				core.NewLog(core.Meta{Date: time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)}, "Summary on the next line\n\nBody\ntags: tag0, tag1", nil),
+				core.NewLog(core.Meta{Date: time.Date(2022, 5, 16, 21, 0, 0, 0, time.Local)}, "Second entry", nil),
+			},
+		},
+		{
+			format: FormatDayOne,
+			input:  `{"entries": [{"creationDate": "2022-05-16T16:20:00Z", "timeZone": "Europe/Helsinki", "text": "Day One entry\n\nBody", "tags": ["tag0"]}]}`,
+			expected: []core.Log{
+				core.NewLog(core.Meta{Date: time.Date(2022, 5, 16, 19, 20, 0, 0, helsinki)}, "Day One entry\n\nBody", []string{"tag0"}),
+			},
+		},
+		{
+			format:     FormatDayOne,
+			input:      `{"entries": [{"creationDate": "yesterday"}]}`,
+			expectsErr: true,
+		},
+		{
+			format:     "evernote",
+			expectsErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			actual, err := Read(tt.format, strings.NewReader(tt.input), tt.page)
+			if (err != nil) != tt.expectsErr {
+				t.Fatalf("expects error %t did not match actual %v", tt.expectsErr, err)
+			}
+
+			if len(tt.expected) != len(actual) {
+				t.Fatalf("expected logs %v did not equal to actual logs %v", tt.expected, actual)
+			}
+
+			for i := range actual {
+				if !tt.expected[i].Date.Equal(actual[i].Date) || !reflect.DeepEqual(tt.expected[i].Data, actual[i].Data) || tt.expected[i].Page != actual[i].Page {
+					t.Fatalf("expected log %#v did not equal to actual log %#v", tt.expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package layout maps log files to paths in a workspace with path templates
+// like `{{year}}/{{month}}/{{yyyy-mm-dd}}.md`.
+package layout
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Default is the flat layout with all log files of a page in one directory
+const Default = "{{dd-mm-yyyy}}.log.md"
+
+var (
+	ErrUnknownPlaceholderF = func(p string) error { return fmt.Errorf("unknown placeholder \"{{%s}}\"", p) }
+	ErrIncompleteDate      = errors.New("layout needs the year, month and day of the log file")
+	ErrInvalidPath         = errors.New("layout must be a relative path inside the workspace")
+	ErrInvalidLayoutF      = func(template string, e error) error { return fmt.Errorf("invalid layout \"%s\" - %w", template, e) }
+)
+
+// placeholders map the placeholders of the template to time layouts and to
+// the date components they contain
+var placeholders = map[string]struct {
+	layout string
+	year   bool
+	month  bool
+	day    bool
+}{
+	"year":       {layout: "2006", year: true},
+	"month":      {layout: "01", month: true},
+	"day":        {layout: "02", day: true},
+	"yyyy-mm-dd": {layout: "2006-01-02", year: true, month: true, day: true},
+	"dd-mm-yyyy": {layout: "02-01-2006", year: true, month: true, day: true},
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*([a-z-]+)\s*\}\}`)
+
+var digit = regexp.MustCompile(`\d`)
+
+// Layout is a parsed path template.
+type Layout struct {
+	Template string
+	// literals are the text around the placeholders, there is one literal
+	// more than there are placeholders
+	literals []string
+	// layouts are the time layouts of the placeholders in the template
+	layouts []string
+	// match matches a path relative to the workspace, an optional page
+	// directory followed by the path of the template
+	match *regexp.Regexp
+	// dir matches the first directory of the template if it has any
+	dir *regexp.Regexp
+}
+
+// Parse parses the template. An empty template is the default layout.
+func Parse(template string) (Layout, error) {
+	if len(strings.TrimSpace(template)) == 0 {
+		template = Default
+	}
+
+	l := Layout{Template: template}
+
+	if path.IsAbs(template) || path.Clean(template) != template || strings.HasPrefix(template, "..") {
+		return l, ErrInvalidLayoutF(template, ErrInvalidPath)
+	}
+
+	var (
+		year, month, day bool
+		pattern          strings.Builder
+	)
+
+	last := 0
+	for _, m := range placeholder.FindAllStringSubmatchIndex(template, -1) {
+		name := template[m[2]:m[3]]
+
+		p, ok := placeholders[name]
+		if !ok {
+			return l, ErrInvalidLayoutF(template, ErrUnknownPlaceholderF(name))
+		}
+
+		year, month, day = year || p.year, month || p.month, day || p.day
+
+		literal := template[last:m[0]]
+		pattern.WriteString(regexp.QuoteMeta(literal))
+		pattern.WriteString("(" + digit.ReplaceAllString(regexp.QuoteMeta(p.layout), `\d`) + ")")
+
+		l.literals = append(l.literals, literal)
+		l.layouts = append(l.layouts, p.layout)
+		last = m[1]
+	}
+
+	if !year || !month || !day {
+		return l, ErrInvalidLayoutF(template, ErrIncompleteDate)
+	}
+
+	l.literals = append(l.literals, template[last:])
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+
+	l.match = regexp.MustCompile(`^(?:(.+)/)?` + pattern.String() + `$`)
+
+	if dir, _, ok := strings.Cut(pattern.String(), "/"); ok {
+		l.dir = regexp.MustCompile(`^` + dir + `$`)
+	}
+
+	return l, nil
+}
+
+// Path returns the path of the log file of the date relative to the page.
+func (l Layout) Path(date time.Time) string {
+	var b strings.Builder
+
+	for i, layout := range l.layouts {
+		b.WriteString(l.literals[i])
+		b.WriteString(date.Format(layout))
+	}
+	b.WriteString(l.literals[len(l.literals)-1])
+
+	return b.String()
+}
+
+// Match parses the page and the date from the path of a log file relative to
+// the workspace. The path does not match when it is not a log file path of
+// the layout.
+func (l Layout) Match(rel string) (string, time.Time, bool) {
+	m := l.match.FindStringSubmatch(path.Clean(strings.ReplaceAll(rel, "\\", "/")))
+	if m == nil {
+		return "", time.Time{}, false
+	}
+
+	// Values of all placeholders are parsed together, so a day in the file
+	// name is combined with a year and a month in the directories
+	date, err := time.ParseInLocation(strings.Join(l.layouts, " "), strings.Join(m[2:], " "), time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	// Placeholders repeating a date component need to agree with each other
+	if l.Path(date) != strings.TrimPrefix(m[0], m[1]+"/") {
+		return "", time.Time{}, false
+	}
+
+	return m[1], date, true
+}
+
+// IsLayoutDir reports whether the directory in the root of a page is part of
+// the layout instead of being a page of its own.
+func (l Layout) IsLayoutDir(name string) bool {
+	return l.dir != nil && l.dir.MatchString(name)
+}
@@ -0,0 +1,128 @@
+package layout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		template string
+		err      bool
+	}{
+		{template: ""},
+		{template: Default},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md"},
+		{template: "{{year}}/{{month}}-{{day}}.log.md"},
+		{template: "{{year}}/{{month}}/notes.md", err: true},
+		{template: "{{week}}/{{yyyy-mm-dd}}.md", err: true},
+		{template: "/{{yyyy-mm-dd}}.md", err: true},
+		{template: "../{{yyyy-mm-dd}}.md", err: true},
+		{template: "{{year}}//{{yyyy-mm-dd}}.md", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if _, err := Parse(tt.template); tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	date := time.Date(2022, 5, 16, 12, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{template: "", expected: "16-05-2022.log.md"},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", expected: "2022/05/2022-05-16.md"},
+		{template: "Jan{{ day }}-{{month}}-{{year}}.md", expected: "Jan16-05-2022.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			l, err := Parse(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := l.Path(date); actual != tt.expected {
+				t.Fatalf("path did not match expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	date := time.Date(2022, 5, 16, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		template string
+		path     string
+		page     string
+		ok       bool
+	}{
+		{template: "", path: "16-05-2022.log.md", ok: true},
+		{template: "", path: "work/16-05-2022.log.md", page: "work", ok: true},
+		{template: "", path: "16-05-2022.md"},
+		{template: "", path: "2022/05/2022-05-16.md"},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", path: "2022/05/2022-05-16.md", ok: true},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", path: "work/2022/05/2022-05-16.md", page: "work", ok: true},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", path: "work/a/2022/05/2022-05-16.md", page: "work/a", ok: true},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", path: "2021/05/2022-05-16.md"},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", path: "16-05-2022.log.md"},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", path: "2022/13/2022-13-16.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			l, err := Parse(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			page, actual, ok := l.Match(tt.path)
+
+			if ok != tt.ok {
+				t.Fatalf("expected match %t, got %t", tt.ok, ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if page != tt.page || !actual.Equal(date) {
+				t.Fatalf("expected %s on %s, got %s on %s", tt.page, date, page, actual)
+			}
+		})
+	}
+}
+
+func TestIsLayoutDir(t *testing.T) {
+	tests := []struct {
+		template string
+		dir      string
+		expected bool
+	}{
+		{template: "", dir: "2022"},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", dir: "2022", expected: true},
+		{template: "{{year}}/{{month}}/{{yyyy-mm-dd}}.md", dir: "work"},
+		{template: "logs/{{yyyy-mm-dd}}.md", dir: "logs", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			l, err := Parse(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := l.IsLayoutDir(tt.dir); actual != tt.expected {
+				t.Fatalf("expected %t, got %t", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Package locale formats and parses dates with weekday and month names of
+// other languages than English, which is the only language of package time.
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default is the locale of package time
+const Default = "en"
+
+var ErrUnknownLocaleF = func(name string) error {
+	return fmt.Errorf("unknown locale \"%s\", supported locales are: %s", name, strings.Join(Names(), ", "))
+}
+
+// Locale has the weekday and month names of a language.
+type Locale struct {
+	Name        string
+	days        [7]string
+	shortDays   [7]string
+	months      [12]string
+	shortMonths [12]string
+}
+
+var english = Locale{
+	Name:        "en",
+	days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	shortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+	shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+}
+
+var locales = map[string]Locale{
+	"en": english,
+	"fi": {
+		Name:        "fi",
+		days:        [7]string{"sunnuntai", "maanantai", "tiistai", "keskiviikko", "torstai", "perjantai", "lauantai"},
+		shortDays:   [7]string{"su", "ma", "ti", "ke", "to", "pe", "la"},
+		months:      [12]string{"tammikuu", "helmikuu", "maaliskuu", "huhtikuu", "toukokuu", "kesäkuu", "heinäkuu", "elokuu", "syyskuu", "lokakuu", "marraskuu", "joulukuu"},
+		shortMonths: [12]string{"tammi", "helmi", "maalis", "huhti", "touko", "kesä", "heinä", "elo", "syys", "loka", "marras", "joulu"},
+	},
+	"sv": {
+		Name:        "sv",
+		days:        [7]string{"söndag", "måndag", "tisdag", "onsdag", "torsdag", "fredag", "lördag"},
+		shortDays:   [7]string{"sön", "mån", "tis", "ons", "tors", "fre", "lör"},
+		months:      [12]string{"januari", "februari", "mars", "april", "maj", "juni", "juli", "augusti", "september", "oktober", "november", "december"},
+		shortMonths: [12]string{"jan", "feb", "mar", "apr", "maj", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+	},
+	"de": {
+		Name:        "de",
+		days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+		months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+	},
+	"fr": {
+		Name:        "fr",
+		days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   [7]string{"dim", "lun", "mar", "mer", "jeu", "ven", "sam"},
+		months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: [12]string{"janv", "févr", "mars", "avr", "mai", "juin", "juil", "août", "sept", "oct", "nov", "déc"},
+	},
+	"es": {
+		Name:        "es",
+		days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+	},
+}
+
+// Names returns the names of the supported locales.
+func Names() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Lookup returns the locale by its name. Names like fi_FI.UTF-8 are looked
+// up by the language. An empty name is the default locale.
+func Lookup(name string) (Locale, error) {
+	if len(name) == 0 {
+		return english, nil
+	}
+
+	lang := strings.ToLower(name)
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+
+	l, ok := locales[lang]
+	if !ok {
+		return Locale{}, ErrUnknownLocaleF(name)
+	}
+
+	return l, nil
+}
+
+// nameElements are the elements of time layouts replaced with the names of
+// the locale. Longer elements are matched first like in package time.
+var nameElements = []string{"January", "Jan", "Monday", "Mon"}
+
+// Format formats the time like time.Format with the names of the locale.
+func (l Locale) Format(t time.Time, layout string) string {
+	var b strings.Builder
+
+	for len(layout) > 0 {
+		i, element := nextNameElement(layout)
+		if i < 0 {
+			b.WriteString(t.Format(layout))
+			break
+		}
+
+		b.WriteString(t.Format(layout[:i]))
+
+		switch element {
+		case "January":
+			b.WriteString(l.months[t.Month()-1])
+		case "Jan":
+			b.WriteString(l.shortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(l.days[t.Weekday()])
+		case "Mon":
+			b.WriteString(l.shortDays[t.Weekday()])
+		}
+
+		layout = layout[i+len(element):]
+	}
+
+	return b.String()
+}
+
+// nextNameElement returns the position of the next weekday or month name
+// element in the layout.
+func nextNameElement(layout string) (int, string) {
+	for i := 0; i < len(layout); i++ {
+		for _, e := range nameElements {
+			if strings.HasPrefix(layout[i:], e) {
+				return i, e
+			}
+		}
+	}
+
+	return -1, ""
+}
+
+// Parse parses the value formatted with the layout in the locale.
+func (l Locale) Parse(layout string, value string, loc *time.Location) (time.Time, error) {
+	if l.Name != english.Name {
+		value = l.replacer(layout).Replace(value)
+	}
+
+	return time.ParseInLocation(layout, value, loc)
+}
+
+// replacer replaces the names of the locale used in the layout with the
+// English names. Only names used in the layout are replaced as weekday and
+// month abbreviations may be the same. Longer names are replaced first, so
+// that full names are not replaced by their abbreviations.
+func (l Locale) replacer(layout string) *strings.Replacer {
+	type pair struct{ from, to string }
+
+	var pairs []pair
+	for rest := layout; ; {
+		i, element := nextNameElement(rest)
+		if i < 0 {
+			break
+		}
+		rest = rest[i+len(element):]
+
+		switch element {
+		case "January":
+			for m := range l.months {
+				pairs = append(pairs, pair{l.months[m], english.months[m]})
+			}
+		case "Jan":
+			for m := range l.shortMonths {
+				pairs = append(pairs, pair{l.shortMonths[m], english.shortMonths[m]})
+			}
+		case "Monday":
+			for d := range l.days {
+				pairs = append(pairs, pair{l.days[d], english.days[d]})
+			}
+		case "Mon":
+			for d := range l.shortDays {
+				pairs = append(pairs, pair{l.shortDays[d], english.shortDays[d]})
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool { return len(pairs[i].from) > len(pairs[j].from) })
+
+	oldnew := make([]string, 0, len(pairs)*2)
+	for _, p := range pairs {
+		oldnew = append(oldnew, p.from, p.to)
+	}
+
+	return strings.NewReplacer(oldnew...)
+}
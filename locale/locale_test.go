@@ -0,0 +1,75 @@
+package locale
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		err      bool
+	}{
+		{name: "", expected: "en"},
+		{name: "fi", expected: "fi"},
+		{name: "fi_FI.UTF-8", expected: "fi"},
+		{name: "de-DE", expected: "de"},
+		{name: "xx", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			l, err := Lookup(tt.name)
+
+			if tt.err != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.err, err)
+			}
+
+			if l.Name != tt.expected {
+				t.Fatalf("expected locale %s, got %s", tt.expected, l.Name)
+			}
+		})
+	}
+}
+
+func TestFormatAndParse(t *testing.T) {
+	date := time.Date(2022, 3, 15, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		locale   string
+		layout   string
+		expected string
+	}{
+		{locale: "en", layout: "Monday, January 2, 2006", expected: "Tuesday, March 15, 2022"},
+		{locale: "fi", layout: "Monday 2. January 2006", expected: "tiistai 15. maaliskuu 2022"},
+		{locale: "fi", layout: "Mon 2.1.2006", expected: "ti 15.3.2022"},
+		{locale: "de", layout: "Monday, 2. January 2006", expected: "Dienstag, 15. März 2022"},
+		{locale: "fr", layout: "Mon 2 Jan 2006", expected: "mar 15 mars 2022"},
+		{locale: "es", layout: "Monday, 2 de January de 2006", expected: "martes, 15 de marzo de 2022"},
+		{locale: "sv", layout: "2006-01-02", expected: "2022-03-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			l, err := Lookup(tt.locale)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := l.Format(date, tt.layout)
+			if actual != tt.expected {
+				t.Fatalf("formatted date did not match expected %s, got %s", tt.expected, actual)
+			}
+
+			parsed, err := l.Parse(tt.layout, actual, time.Local)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !parsed.Equal(date) {
+				t.Fatalf("parsed date did not match expected %s, got %s", date, parsed)
+			}
+		})
+	}
+}
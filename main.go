@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/erikjuhani/caplog/cli"
 	"github.com/erikjuhani/caplog/config"
@@ -15,6 +16,7 @@ func main() {
 	}
 
 	if err := cli.Run(); err != nil {
-		log.Fatalf("error: %s", err)
+		log.Printf("error: %s", err)
+		os.Exit(cli.ExitCode(err))
 	}
 }
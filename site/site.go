@@ -0,0 +1,477 @@
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/erikjuhani/caplog/config"
+	"github.com/erikjuhani/caplog/core"
+)
+
+const (
+	daySlugFormat   = "2006-01-02"
+	monthSlugFormat = "2006-01"
+	rootPageName    = "root"
+	// manifestFilename is the file listing the pages generated in the output
+	// directory, so the next build removes only the pages it generated
+	manifestFilename = ".caplog-site"
+)
+
+var ErrNoOutputDir = fmt.Errorf("no output directory provided")
+
+type link struct {
+	Title string
+	Href  string
+}
+
+type tag struct {
+	Name string
+	Slug string
+	// Size is the relative usage of the tag between 1 and 5 used in tag cloud
+	Size  int
+	Count int
+}
+
+type entry struct {
+	core.Log
+	Day string
+	// PageSlug is the unique slug of the page of the log entry
+	PageSlug string
+	Tags     []tag
+}
+
+// group is a titled list of log entries, which is used for days,
+// months, pages and tags
+type group struct {
+	Title   string
+	Slug    string
+	Entries []entry
+}
+
+type page struct {
+	// Root is the relative path from the page to the site root
+	Root    string
+	Site    string
+	Title   string
+	Prev    *link
+	Next    *link
+	Links   []link
+	Groups  []group
+	TagList []tag
+}
+
+type site struct {
+	title  string
+	out    string
+	tags   map[string]*tag
+	days   []group
+	months []group
+	pages  []group
+	byTag  []group
+	// rendered are the files written in the output directory
+	rendered map[string]bool
+}
+
+// Build renders all log entries of the current workspace to a static HTML
+// site in the output directory.
+func Build(out string) error {
+	if len(out) == 0 {
+		return ErrNoOutputDir
+	}
+
+	results, err := core.Search(core.Query{})
+	if err != nil {
+		return err
+	}
+
+	logs := make([]core.Log, len(results))
+	for i, r := range results {
+		logs[i] = r.Log
+	}
+
+	return generate(out, config.Config.CurrentWorkspace, logs)
+}
+
+func generate(out string, title string, logs []core.Log) error {
+	s := newSite(out, title, logs)
+
+	for _, dir := range []string{"days", "months", "pages", "tags"} {
+		if err := os.MkdirAll(filepath.Join(out, dir), os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(out, "style.css"), []byte(stylesheet), 0644); err != nil {
+		return err
+	}
+
+	if err := s.render("index.html", s.index()); err != nil {
+		return err
+	}
+
+	for i, g := range s.days {
+		if err := s.render(filepath.Join("days", g.Slug+".html"), s.page(g, i, s.days)); err != nil {
+			return err
+		}
+	}
+
+	for i, g := range s.months {
+		p := s.page(g, i, s.months)
+		p.Groups = groupByDay(g.Entries)
+		if err := s.render(filepath.Join("months", g.Slug+".html"), p); err != nil {
+			return err
+		}
+	}
+
+	for dir, groups := range map[string][]group{"pages": s.pages, "tags": s.byTag} {
+		for _, g := range groups {
+			p := s.page(g, -1, nil)
+			p.Groups = groupByDay(g.Entries)
+			if err := s.render(filepath.Join(dir, g.Slug+".html"), p); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.removeStale(); err != nil {
+		return err
+	}
+
+	return s.writeManifest()
+}
+
+func newSite(out string, title string, logs []core.Log) *site {
+	s := &site{title: title, out: out, tags: map[string]*tag{}, rendered: map[string]bool{}}
+
+	slugs := map[string]bool{}
+	pageSlug := pageSlugs(logs)
+
+	var entries []entry
+	for _, l := range logs {
+		e := entry{Log: l, Day: l.Date.Format(daySlugFormat), PageSlug: pageSlug[l.Page]}
+
+		for _, name := range l.Tags() {
+			t, ok := s.tags[name]
+			if !ok {
+				t = &tag{Name: name, Slug: uniqueSlug(slugs, slugify(name))}
+				s.tags[name] = t
+			}
+			t.Count++
+			e.Tags = append(e.Tags, *t)
+		}
+
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	s.days = groupByDay(entries)
+	s.months = groupBy(entries, func(e entry) (string, string) {
+		return e.Date.Format(monthSlugFormat), e.Date.Format("January 2006")
+	})
+
+	s.pages = groupBy(entries, func(e entry) (string, string) {
+		if len(e.Page) == 0 {
+			return e.PageSlug, rootPageName
+		}
+		return e.PageSlug, e.Page
+	})
+	sort.Slice(s.pages, func(i, j int) bool { return s.pages[i].Title < s.pages[j].Title })
+
+	for _, t := range s.tagList() {
+		g := group{Title: t.Name, Slug: t.Slug}
+		for _, e := range entries {
+			for _, et := range e.Tags {
+				if et.Name == t.Name {
+					g.Entries = append(g.Entries, e)
+					break
+				}
+			}
+		}
+		s.byTag = append(s.byTag, g)
+	}
+
+	return s
+}
+
+func (s *site) index() page {
+	p := page{Site: s.title, Title: s.title, TagList: s.tagList()}
+
+	for i := len(s.months) - 1; i >= 0; i-- {
+		p.Links = append(p.Links, link{Title: s.months[i].Title, Href: "months/" + s.months[i].Slug + ".html"})
+	}
+
+	for _, g := range s.pages {
+		p.Links = append(p.Links, link{Title: "Page: " + g.Title, Href: "pages/" + g.Slug + ".html"})
+	}
+
+	// Latest days are shown directly on the front page
+	latest := s.days
+	if len(latest) > 7 {
+		latest = latest[len(latest)-7:]
+	}
+	for i := len(latest) - 1; i >= 0; i-- {
+		p.Groups = append(p.Groups, latest[i])
+	}
+
+	return p
+}
+
+// page creates a page of the group in a sub-directory of the site with links
+// to previous and next groups when the group is part of an ordered list.
+func (s *site) page(g group, i int, groups []group) page {
+	p := page{Root: "../", Site: s.title, Title: g.Title, Groups: []group{g}}
+
+	if i > 0 {
+		prev := groups[i-1]
+		p.Prev = &link{Title: prev.Title, Href: prev.Slug + ".html"}
+	}
+
+	if i >= 0 && i < len(groups)-1 {
+		next := groups[i+1]
+		p.Next = &link{Title: next.Title, Href: next.Slug + ".html"}
+	}
+
+	return p
+}
+
+func (s *site) render(name string, p page) error {
+	f, err := os.Create(filepath.Join(s.out, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s.rendered[name] = true
+
+	return templates.ExecuteTemplate(f, "page", p)
+}
+
+// removeStale removes the pages left in the output directory by the previous
+// build, such as the pages of removed log entries and renamed tags. Only the
+// pages listed in the manifest of the previous build are removed, so other
+// files in the output directory are kept.
+func (s *site) removeStale() error {
+	b, err := os.ReadFile(filepath.Join(s.out, manifestFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, name := range strings.Split(string(b), "\n") {
+		name = filepath.FromSlash(name)
+
+		// Manifest is not trusted to point outside the output directory
+		if len(name) == 0 || s.rendered[name] || name != filepath.Clean(name) || filepath.IsAbs(name) || strings.HasPrefix(name, "..") {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(s.out, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeManifest lists the pages generated by the build in the manifest.
+func (s *site) writeManifest() error {
+	names := make([]string, 0, len(s.rendered))
+	for name := range s.rendered {
+		names = append(names, filepath.ToSlash(name))
+	}
+
+	sort.Strings(names)
+
+	return os.WriteFile(filepath.Join(s.out, manifestFilename), []byte(strings.Join(names, "\n")+"\n"), 0644)
+}
+
+// tagList returns the tags ordered by name with sizes relative to the most
+// used tag.
+func (s *site) tagList() []tag {
+	var (
+		tags []tag
+		max  int
+	)
+
+	for _, t := range s.tags {
+		if t.Count > max {
+			max = t.Count
+		}
+	}
+
+	for _, t := range s.tags {
+		tt := *t
+		tt.Size = 1 + 4*tt.Count/max
+		if tt.Size > 5 {
+			tt.Size = 5
+		}
+		tags = append(tags, tt)
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	return tags
+}
+
+func groupByDay(entries []entry) []group {
+	return groupBy(entries, func(e entry) (string, string) {
+		return e.Day, config.Locale().Format(e.Date, config.DateFormat())
+	})
+}
+
+// groupBy groups entries by the slug and title given by key preserving the
+// order in which the groups first appear.
+func groupBy(entries []entry, key func(entry) (string, string)) []group {
+	var groups []group
+	index := map[string]int{}
+
+	for _, e := range entries {
+		slug, title := key(e)
+
+		i, ok := index[slug]
+		if !ok {
+			i = len(groups)
+			index[slug] = i
+			groups = append(groups, group{Title: title, Slug: slug})
+		}
+
+		groups[i].Entries = append(groups[i].Entries, e)
+	}
+
+	return groups
+}
+
+// slugify converts the name to a lowercase file name safe slug
+func slugify(name string) string {
+	slug := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '-'
+	}, name)
+
+	if slug = strings.Trim(slug, "-"); len(slug) == 0 {
+		return "-"
+	}
+
+	return slug
+}
+
+// pageSlugs returns unique slugs for the pages of the log entries. Pages are
+// given slugs in alphabetical order after the root page, so the slugs do not
+// change with the order of the log entries and no page takes the slug of the
+// root page.
+func pageSlugs(logs []core.Log) map[string]string {
+	var pages []string
+	for _, l := range logs {
+		if len(l.Page) > 0 {
+			pages = append(pages, l.Page)
+		}
+	}
+
+	sort.Strings(pages)
+
+	taken := map[string]bool{}
+	slugs := map[string]string{"": uniqueSlug(taken, rootPageName)}
+
+	for _, page := range pages {
+		if _, ok := slugs[page]; !ok {
+			slugs[page] = uniqueSlug(taken, slugify(page))
+		}
+	}
+
+	return slugs
+}
+
+// uniqueSlug returns the slug with a numbered suffix if the slug is
+// already taken
+func uniqueSlug(taken map[string]bool, slug string) string {
+	unique := slug
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", slug, i)
+	}
+
+	taken[unique] = true
+
+	return unique
+}
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"clock": func(t time.Time) string {
+		return t.Format(config.TimeFormat())
+	},
+	"entryData": func(root string, e entry) map[string]interface{} {
+		return map[string]interface{}{"Root": root, "Entry": e}
+	},
+}).Parse(`
+{{define "page"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if ne .Title .Site}}{{.Title}} - {{end}}{{.Site}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header><a href="{{.Root}}index.html">{{.Site}}</a></header>
+<main>
+<h1>{{.Title}}</h1>
+{{template "nav" .}}
+{{if .Links}}<ul class="links">{{range .Links}}<li><a href="{{.Href}}">{{.Title}}</a></li>{{end}}</ul>{{end}}
+{{if .TagList}}<p class="tag-cloud">{{range .TagList}}<a class="tag size-{{.Size}}" href="tags/{{.Slug}}.html">{{.Name}}</a> {{end}}</p>{{end}}
+{{$root := .Root}}{{range .Groups}}<section>
+<h2><a href="{{$root}}days/{{(index .Entries 0).Day}}.html">{{.Title}}</a></h2>
+{{range .Entries}}{{template "entry" (entryData $root .)}}{{end}}
+</section>
+{{end}}
+{{template "nav" .}}
+</main>
+</body>
+</html>
+{{end}}
+
+{{define "nav"}}{{if or .Prev .Next}}<nav>{{with .Prev}}<a class="prev" href="{{.Href}}">&larr; {{.Title}}</a>{{end}} {{with .Next}}<a class="next" href="{{.Href}}">{{.Title}} &rarr;</a>{{end}}</nav>{{end}}{{end}}
+
+{{define "entry"}}<article>
+<h3><time datetime="{{.Entry.Date.Format "2006-01-02T15:04:05Z07:00"}}">{{clock .Entry.Date}}</time> {{.Entry.Summary}}{{if .Entry.Page}} <a class="page" href="{{.Root}}pages/{{.Entry.PageSlug}}.html">{{.Entry.Page}}</a>{{end}}</h3>
+{{with .Entry.Body}}<pre>{{.}}</pre>{{end}}
+{{if .Entry.Tags}}<p class="tags">{{$root := .Root}}{{range .Entry.Tags}}<a class="tag" href="{{$root}}tags/{{.Slug}}.html">{{.Name}}</a> {{end}}</p>{{end}}
+</article>
+{{end}}
+`[1:]))
+
+const stylesheet = `body {
+  margin: 0 auto;
+  max-width: 48rem;
+  padding: 1rem;
+  font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif;
+  line-height: 1.5;
+  color: #1f2328;
+}
+header a { font-weight: bold; text-decoration: none; }
+h2 a { color: inherit; text-decoration: none; }
+h3 { font-size: 1rem; }
+time { color: #9a6700; margin-right: 0.5rem; }
+pre { white-space: pre-wrap; font-family: inherit; margin: 0 0 0 3.5rem; }
+nav { display: flex; justify-content: space-between; margin: 1rem 0; }
+.links { columns: 2; }
+.tags { margin-left: 3.5rem; }
+.tag { color: #0969da; margin-right: 0.25rem; }
+.page { font-size: 0.8rem; color: #57606a; margin-left: 0.5rem; }
+.tag-cloud .size-1 { font-size: 0.8rem; }
+.tag-cloud .size-2 { font-size: 1rem; }
+.tag-cloud .size-3 { font-size: 1.2rem; }
+.tag-cloud .size-4 { font-size: 1.4rem; }
+.tag-cloud .size-5 { font-size: 1.6rem; }
+`
@@ -0,0 +1,191 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erikjuhani/caplog/core"
+)
+
+func TestGenerate(t *testing.T) {
+	out, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	logs := []core.Log{
+		core.NewLog(core.Meta{Date: testDate}, "First <entry>\n\nWith body", []string{"go", "Go"}),
+		core.NewLog(core.Meta{Date: testDate.AddDate(0, 0, 1), Page: "work"}, "Second entry", []string{"go"}),
+		core.NewLog(core.Meta{Date: testDate.AddDate(0, 1, 0)}, "Third entry", nil),
+	}
+
+	if err := generate(out, "test", logs); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedFiles := []string{
+		"index.html",
+		"style.css",
+		"days/2022-05-16.html",
+		"days/2022-05-17.html",
+		"days/2022-06-16.html",
+		"months/2022-05.html",
+		"months/2022-06.html",
+		"pages/root.html",
+		"pages/work.html",
+		"tags/go.html",
+		"tags/go-2.html",
+	}
+
+	for _, f := range expectedFiles {
+		if _, err := os.Stat(filepath.Join(out, f)); err != nil {
+			t.Fatalf("expected file %s was not generated", f)
+		}
+	}
+
+	day, err := os.ReadFile(filepath.Join(out, "days/2022-05-17.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{`href="2022-05-16.html"`, `href="2022-06-16.html"`, `href="../tags/go.html"`, `href="../pages/work.html"`} {
+		if !strings.Contains(string(day), expected) {
+			t.Fatalf("expected day page to contain %s", expected)
+		}
+	}
+
+	// All links need to be relative and point to generated files
+	href := regexp.MustCompile(`(?:href|src)="([^"]+)"`)
+
+	err = filepath.Walk(out, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != ".html" {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if strings.Contains(string(content), "<entry>") {
+			t.Fatalf("expected log entry content to be escaped in %s", path)
+		}
+
+		for _, m := range href.FindAllStringSubmatch(string(content), -1) {
+			if _, err := os.Stat(filepath.Join(filepath.Dir(path), m[1])); err != nil {
+				t.Fatalf("link %s in %s does not point to a generated file", m[1], path)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files not generated by the site are kept when the site is regenerated
+	userFiles := []string{"days/notes.html", "about.html"}
+	for _, f := range userFiles {
+		if err := os.WriteFile(filepath.Join(out, f), []byte("notes"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Pages of removed log entries are removed when the site is regenerated
+	if err := generate(out, "test", logs[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range userFiles {
+		if _, err := os.Stat(filepath.Join(out, f)); err != nil {
+			t.Fatalf("expected file %s to be kept, got %v", f, err)
+		}
+	}
+
+	for _, f := range []string{"days/2022-05-17.html", "months/2022-06.html", "pages/work.html"} {
+		if _, err := os.Stat(filepath.Join(out, f)); !os.IsNotExist(err) {
+			t.Fatalf("expected stale file %s to be removed, got %v", f, err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "days/2022-05-16.html")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateWithoutManifest(t *testing.T) {
+	out, err := os.MkdirTemp("", "caplog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+
+	// Existing directory without a manifest is not cleaned up
+	if err := os.MkdirAll(filepath.Join(out, "pages"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := filepath.Join(out, "pages", "home.html")
+	if err := os.WriteFile(existing, []byte("home"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := []core.Log{core.NewLog(core.Meta{Date: time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)}, "Entry", nil)}
+
+	if err := generate(out, "test", logs); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(existing); err != nil {
+		t.Fatalf("expected existing file to be kept, got %v", err)
+	}
+
+	// Manifest cannot remove files outside of the output directory
+	outside := out + ".txt"
+	if err := os.WriteFile(outside, []byte("outside"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(outside)
+
+	manifest := "../" + filepath.Base(outside) + "\n"
+	if err := os.WriteFile(filepath.Join(out, manifestFilename), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := generate(out, "test", logs); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("expected file outside of the output directory to be kept, got %v", err)
+	}
+}
+
+func TestPageSlugs(t *testing.T) {
+	testDate := time.Date(2022, 5, 16, 19, 20, 0, 0, time.Local)
+
+	var logs []core.Log
+	for _, page := range []string{"work-sub", "", "root", "work/sub", "Work/Sub"} {
+		logs = append(logs, core.NewLog(core.Meta{Date: testDate, Page: page}, "Entry", nil))
+	}
+
+	expected := map[string]string{
+		"":         "root",
+		"Work/Sub": "work-sub",
+		"root":     "root-2",
+		"work-sub": "work-sub-2",
+		"work/sub": "work-sub-3",
+	}
+
+	if actual := pageSlugs(logs); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected page slugs %v did not equal to actual page slugs %v", expected, actual)
+	}
+}